@@ -2,9 +2,15 @@ package captcha
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 // MockCaptchaStore 模拟验证码存储用于测试
@@ -45,6 +51,26 @@ func (m *MockCaptchaStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *MockCaptchaStore) Take(ctx context.Context, id string) (string, error) {
+	value, err := m.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	_ = m.Delete(ctx, id)
+	return value, nil
+}
+
+func (m *MockCaptchaStore) Verify(ctx context.Context, id, answer string, maxAttempts int) (bool, error) {
+	value, err := m.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if value == answer || maxAttempts == 1 {
+		_ = m.Delete(ctx, id)
+	}
+	return value == answer, nil
+}
+
 func TestImageCaptchaService(t *testing.T) {
 	store := NewMockCaptchaStore()
 	
@@ -137,3 +163,141 @@ func TestCaptchaExpiration(t *testing.T) {
 	}
 }
 
+
+func TestCaptchaTypes(t *testing.T) {
+	ctx := context.Background()
+	service := New(NewMemoryCaptchaStore())
+
+	tests := []struct {
+		name string
+		opt  CaptchaOption
+	}{
+		{name: "digit", opt: CaptchaOption{Type: CaptchaTypeDigit, Complexity: ComplexityHigh}},
+		{name: "string", opt: CaptchaOption{Type: CaptchaTypeString, Length: 6}},
+		{name: "math", opt: CaptchaOption{Type: CaptchaTypeMath, Complexity: ComplexityLow}},
+		{name: "chinese", opt: CaptchaOption{Type: CaptchaTypeChinese, Length: 2, Width: 160, Height: 60}},
+		{name: "audio", opt: CaptchaOption{Type: CaptchaTypeAudio, Language: "zh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.GenerateCaptcha(ctx, tt.opt)
+			if err != nil {
+				t.Fatalf("GenerateCaptcha failed: %v", err)
+			}
+			if resp.Type != tt.opt.Type {
+				t.Fatalf("expected type %s, got %s", tt.opt.Type, resp.Type)
+			}
+
+			if tt.opt.Type == CaptchaTypeAudio {
+				if !strings.HasPrefix(resp.AudioBase64, "data:audio/wav;base64,") || resp.Image != nil {
+					t.Fatalf("unexpected audio response: %+v", resp)
+				}
+			} else if resp.Image == nil || !strings.HasPrefix(resp.ImageBase64, "data:image/png;base64,") {
+				t.Fatalf("unexpected image response: %+v", resp)
+			}
+
+			switch tt.opt.Type {
+			case CaptchaTypeString:
+				if len(resp.Value) != 6 || strings.ContainsAny(resp.Value, "01OIL") {
+					t.Fatalf("unexpected alphanumeric value %q", resp.Value)
+				}
+			case CaptchaTypeMath:
+				if _, err := strconv.Atoi(resp.Value); err != nil {
+					t.Fatalf("expected numeric answer, got %q", resp.Value)
+				}
+			case CaptchaTypeChinese:
+				if utf8.RuneCountInString(resp.Value) != 2 {
+					t.Fatalf("expected 2 characters, got %q", resp.Value)
+				}
+			}
+
+			ok, err := service.VerifyCaptcha(ctx, resp.ID, strings.ToLower(resp.Value))
+			if err != nil || !ok {
+				t.Fatalf("VerifyCaptcha = %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestServiceDefaultType(t *testing.T) {
+	service := New(NewMemoryCaptchaStore(), CaptchaOption{Type: CaptchaTypeMath})
+
+	resp, err := service.GenerateImageCaptcha(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("GenerateImageCaptcha failed: %v", err)
+	}
+	if resp.Type != CaptchaTypeMath {
+		t.Fatalf("expected math captcha, got %s", resp.Type)
+	}
+
+	if _, err = service.GenerateCaptcha(context.Background(), CaptchaOption{Type: "unknown"}); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestVerifyMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	service := New(NewMemoryCaptchaStore(), CaptchaOption{MaxAttempts: 2})
+
+	resp, err := service.GenerateImageCaptcha(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("GenerateImageCaptcha failed: %v", err)
+	}
+
+	if ok, err := service.VerifyCaptcha(ctx, resp.ID, "wrong"); ok || err != nil {
+		t.Fatalf("first wrong answer = %v, %v", ok, err)
+	}
+	if ok, err := service.VerifyCaptcha(ctx, resp.ID, "wrong"); ok || err != nil {
+		t.Fatalf("second wrong answer = %v, %v", ok, err)
+	}
+	// 答错次数用完后，正确答案也无法通过
+	if _, err := service.VerifyCaptcha(ctx, resp.ID, resp.Value); !errors.Is(err, ErrCaptchaNotFound) {
+		t.Fatalf("expected ErrCaptchaNotFound after max attempts, got %v", err)
+	}
+}
+
+func TestVerifyConcurrentOnce(t *testing.T) {
+	ctx := context.Background()
+	service := New(NewMemoryCaptchaStore())
+
+	resp, err := service.GenerateImageCaptcha(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("GenerateImageCaptcha failed: %v", err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		passed atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := service.VerifyCaptcha(ctx, resp.ID, resp.Value); ok {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if passed.Load() != 1 {
+		t.Fatalf("expected exactly one successful verification, got %d", passed.Load())
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore()
+
+	if err := store.Set(ctx, "id", "value", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	value, err := store.Take(ctx, "id")
+	if err != nil || value != "value" {
+		t.Fatalf("Take = %q, %v", value, err)
+	}
+	if _, err = store.Take(ctx, "id"); !errors.Is(err, ErrCaptchaNotFound) {
+		t.Fatalf("expected ErrCaptchaNotFound on second Take, got %v", err)
+	}
+}
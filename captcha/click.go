@@ -0,0 +1,245 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/mojocn/base64Captcha"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// clickFont 点选验证码使用的内置中文字体，首次使用时加载
+var clickFont = sync.OnceValue(func() *truetype.Font {
+	return base64Captcha.DefaultEmbeddedFonts.LoadFontByName("fonts/" + chineseFont)
+})
+
+// clickBox 目标字符在图片中的外接矩形
+type clickBox struct {
+	X0 int `json:"x0"`
+	Y0 int `json:"y0"`
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+}
+
+// clickKeyPrefix 点选验证码在 CaptchaStore 中的 key 前缀
+const clickKeyPrefix = "click:"
+
+// DefaultClickCaptchaService 默认文字点选验证码服务
+//
+// 生成时在背景图上随机摆放若干旋转过的汉字，要求用户按顺序点击其中几个；
+// 目标字符的外接矩形保存在 CaptchaStore 中，校验时逐个比较点击坐标。
+// 每个点选验证码只能校验一次。
+type DefaultClickCaptchaService struct {
+	store   CaptchaStore
+	options ClickOption
+}
+
+// NewClickCaptchaService 创建文字点选验证码服务
+func NewClickCaptchaService(store CaptchaStore, options ClickOption) *DefaultClickCaptchaService {
+	if options.ExpireTime == 0 {
+		options.ExpireTime = 2 * time.Minute // 默认2分钟过期
+	}
+	if options.Width == 0 {
+		options.Width = 300 // 默认宽度
+	}
+	if options.Height == 0 {
+		options.Height = 200 // 默认高度
+	}
+	if options.Count == 0 {
+		options.Count = 5 // 默认图上5个字
+	}
+	if options.Targets == 0 {
+		options.Targets = 3 // 默认依次点击3个字
+	}
+	if options.FontSize == 0 {
+		options.FontSize = 32
+	}
+	if options.MaxRotation == 0 {
+		options.MaxRotation = 45
+	}
+	if options.Tolerance == 0 {
+		options.Tolerance = 4
+	}
+	if options.Source == "" {
+		options.Source = base64Captcha.TxtChineseCharaters
+	}
+
+	return &DefaultClickCaptchaService{
+		store:   store,
+		options: options,
+	}
+}
+
+// GenerateClickCaptcha 生成文字点选验证码
+func (s *DefaultClickCaptchaService) GenerateClickCaptcha(ctx context.Context) (*ClickCaptchaResponse, error) {
+	opt := s.options
+	chars := pickChars(opt.Source, opt.Count)
+	if opt.Targets > opt.Count || len(chars) < opt.Count {
+		return nil, ErrInvalidOption
+	}
+
+	bg := backgroundImage(opt.Backgrounds, opt.Width, opt.Height)
+	bounds := bg.Bounds()
+
+	// 每个字占一个格子，格子内随机偏移，保证字符之间不重叠
+	side := opt.FontSize * 3 / 2
+	cols, rows := bounds.Dx()/side, bounds.Dy()/side
+	if cols*rows < opt.Count {
+		return nil, ErrInvalidOption
+	}
+	cells := rand.Perm(cols * rows)[:opt.Count]
+	cellW, cellH := bounds.Dx()/cols, bounds.Dy()/rows
+
+	face := truetype.NewFace(clickFont(), &truetype.Options{Size: float64(opt.FontSize), DPI: 72})
+	defer face.Close()
+
+	boxes := make([]clickBox, opt.Count)
+	for i, ch := range chars {
+		glyph, tight := renderGlyph(face, ch, side, opt.MaxRotation)
+		x := (cells[i]%cols)*cellW + rand.IntN(cellW-side+1)
+		y := (cells[i]/cols)*cellH + rand.IntN(cellH-side+1)
+		draw.Draw(bg, glyph.Bounds().Add(image.Pt(x, y)), glyph, image.Point{}, draw.Over)
+		boxes[i] = clickBox{X0: x + tight.Min.X, Y0: y + tight.Min.Y, X1: x + tight.Max.X, Y1: y + tight.Max.Y}
+	}
+
+	// 前 Targets 个字符是目标，按随机顺序要求点击
+	order := rand.Perm(opt.Targets)
+	targets := make([]string, opt.Targets)
+	answer := make([]clickBox, opt.Targets)
+	points := make([]ClickPoint, opt.Targets)
+	for i, idx := range order {
+		box := boxes[idx]
+		targets[i] = string(chars[idx])
+		answer[i] = box
+		points[i] = ClickPoint{X: (box.X0 + box.X1) / 2, Y: (box.Y0 + box.Y1) / 2}
+	}
+
+	imgBase64, err := encodePNG(bg)
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	id := base64Captcha.RandomId()
+	if err = s.store.Set(ctx, clickKeyPrefix+id, string(value), opt.ExpireTime); err != nil {
+		return nil, err
+	}
+
+	return &ClickCaptchaResponse{
+		CaptchaResponse: CaptchaResponse{
+			ID:          id,
+			Type:        CaptchaTypeClick,
+			Image:       bg,
+			ImageBase64: imgBase64,
+			ExpireAt:    time.Now().Add(opt.ExpireTime),
+			// 注意：在生产环境中，不应返回Value字段，这里仅用于测试
+			Value: strings.Join(targets, ""),
+		},
+		Prompt:  "请依次点击：" + strings.Join(targets, " "),
+		Targets: targets,
+		Width:   bounds.Dx(),
+		Height:  bounds.Dy(),
+		Points:  points,
+	}, nil
+}
+
+// VerifyClick 按顺序校验点击坐标，每个坐标必须落在对应字符的外接矩形内（允许 Tolerance 误差）
+//
+// 无论校验是否通过，验证码都会被删除，失败后需要重新生成。
+func (s *DefaultClickCaptchaService) VerifyClick(ctx context.Context, id string, points []ClickPoint) (bool, error) {
+	stored, err := s.store.Take(ctx, clickKeyPrefix+id)
+	if err != nil {
+		return false, err
+	}
+	var boxes []clickBox
+	if err = json.Unmarshal([]byte(stored), &boxes); err != nil {
+		return false, ErrCaptchaNotFound
+	}
+
+	if len(points) != len(boxes) {
+		return false, nil
+	}
+	tol := s.options.Tolerance
+	for i, p := range points {
+		b := boxes[i]
+		if p.X < b.X0-tol || p.X > b.X1+tol || p.Y < b.Y0-tol || p.Y > b.Y1+tol {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// pickChars 从 source 中随机挑选 n 个不重复的字符
+func pickChars(source string, n int) []rune {
+	seen := make(map[rune]bool)
+	var pool []rune
+	for _, r := range source {
+		if r != ',' && !seen[r] {
+			seen[r] = true
+			pool = append(pool, r)
+		}
+	}
+	if len(pool) < n {
+		return pool
+	}
+	rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	return pool[:n]
+}
+
+// renderGlyph 把字符绘制到 side×side 的透明画布中央并随机旋转，
+// 返回画布和字符笔画的外接矩形
+func renderGlyph(face font.Face, ch rune, side, maxRotation int) (*image.NRGBA, image.Rectangle) {
+	src := image.NewNRGBA(image.Rect(0, 0, side, side))
+	ink := color.NRGBA{R: uint8(rand.IntN(100)), G: uint8(rand.IntN(100)), B: uint8(rand.IntN(100)), A: 255}
+
+	bounds, _ := font.BoundString(face, string(ch))
+	w := (bounds.Max.X - bounds.Min.X).Ceil()
+	h := (bounds.Max.Y - bounds.Min.Y).Ceil()
+	dot := fixed.P((side-w)/2, (side-h)/2).Sub(bounds.Min)
+
+	// 先画一层白色描边再画字，保证在任意背景上都能看清
+	for _, off := range []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		d := &font.Drawer{Dst: src, Src: image.NewUniform(color.NRGBA{R: 255, G: 255, B: 255, A: 200}), Face: face}
+		d.Dot = dot.Add(fixed.P(off.X, off.Y))
+		d.DrawString(string(ch))
+	}
+	d := &font.Drawer{Dst: src, Src: image.NewUniform(ink), Face: face, Dot: dot}
+	d.DrawString(string(ch))
+
+	// 最近邻反向映射旋转
+	angle := float64(rand.IntN(2*maxRotation+1)-maxRotation) * math.Pi / 180
+	sin, cos := math.Sincos(angle)
+	center := float64(side) / 2
+	dst := image.NewNRGBA(src.Bounds())
+	tight := image.Rectangle{}
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			dx, dy := float64(x)+0.5-center, float64(y)+0.5-center
+			sx := int(math.Floor(dx*cos + dy*sin + center))
+			sy := int(math.Floor(-dx*sin + dy*cos + center))
+			if sx < 0 || sy < 0 || sx >= side || sy >= side {
+				continue
+			}
+			c := src.NRGBAAt(sx, sy)
+			if c.A == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, c)
+			tight = tight.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	return dst, tight
+}
@@ -0,0 +1,101 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestClickCaptcha(t *testing.T) {
+	ctx := context.Background()
+	service := NewClickCaptchaService(NewMemoryCaptchaStore(), ClickOption{})
+
+	resp, err := service.GenerateClickCaptcha(ctx)
+	if err != nil {
+		t.Fatalf("GenerateClickCaptcha failed: %v", err)
+	}
+	if resp.Type != CaptchaTypeClick || resp.ImageBase64 == "" || resp.Prompt == "" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if len(resp.Targets) != 3 || len(resp.Points) != 3 {
+		t.Fatalf("expected 3 targets, got %v", resp.Targets)
+	}
+
+	ok, err := service.VerifyClick(ctx, resp.ID, resp.Points)
+	if err != nil || !ok {
+		t.Fatalf("VerifyClick = %v, %v", ok, err)
+	}
+
+	// 每个点选验证码只能校验一次
+	if _, err = service.VerifyClick(ctx, resp.ID, resp.Points); !errors.Is(err, ErrCaptchaNotFound) {
+		t.Fatalf("expected ErrCaptchaNotFound, got %v", err)
+	}
+}
+
+func TestClickCaptchaResponseJSON(t *testing.T) {
+	resp, err := NewClickCaptchaService(NewMemoryCaptchaStore(), ClickOption{}).GenerateClickCaptcha(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateClickCaptcha failed: %v", err)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	if _, ok := fields["points"]; ok {
+		t.Fatal("the answer must not be serialized")
+	}
+	if _, ok := fields["targets"]; !ok {
+		t.Fatalf("expected targets in %s", data)
+	}
+}
+
+func TestClickCaptchaRejects(t *testing.T) {
+	ctx := context.Background()
+	service := NewClickCaptchaService(NewMemoryCaptchaStore(), ClickOption{})
+
+	tests := []struct {
+		name   string
+		points func(p []ClickPoint) []ClickPoint
+	}{
+		{name: "wrong order", points: func(p []ClickPoint) []ClickPoint {
+			return []ClickPoint{p[1], p[0], p[2]}
+		}},
+		{name: "missing click", points: func(p []ClickPoint) []ClickPoint {
+			return p[:2]
+		}},
+		{name: "far away", points: func(p []ClickPoint) []ClickPoint {
+			return []ClickPoint{p[0], p[1], {X: p[2].X + 60, Y: p[2].Y + 60}}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.GenerateClickCaptcha(ctx)
+			if err != nil {
+				t.Fatalf("GenerateClickCaptcha failed: %v", err)
+			}
+			ok, err := service.VerifyClick(ctx, resp.ID, tt.points(resp.Points))
+			if err != nil || ok {
+				t.Fatalf("VerifyClick = %v, %v; want rejection", ok, err)
+			}
+		})
+	}
+}
+
+func TestClickCaptchaInvalidOption(t *testing.T) {
+	ctx := context.Background()
+
+	service := NewClickCaptchaService(NewMemoryCaptchaStore(), ClickOption{Count: 2, Targets: 3})
+	if _, err := service.GenerateClickCaptcha(ctx); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption for Targets > Count, got %v", err)
+	}
+
+	service = NewClickCaptchaService(NewMemoryCaptchaStore(), ClickOption{Width: 100, Height: 50})
+	if _, err := service.GenerateClickCaptcha(ctx); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption for small image, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package captcha
+
+import "errors"
+
+var (
+	// ErrCaptchaNotFound 验证码不存在或已过期
+	ErrCaptchaNotFound = errors.New("captcha: not found")
+	// ErrUnsupportedType 不支持的验证码类型
+	ErrUnsupportedType = errors.New("captcha: unsupported captcha type")
+	// ErrInvalidOption 配置不合法，例如背景图放不下拼图块
+	ErrInvalidOption = errors.New("captcha: invalid option")
+	// ErrInvalidTicket 票据格式、签名或客户端指纹不匹配
+	ErrInvalidTicket = errors.New("captcha: invalid ticket")
+	// ErrTicketExpired 票据已过期
+	ErrTicketExpired = errors.New("captcha: ticket expired")
+	// ErrTicketUsed 票据已经兑换过
+	ErrTicketUsed = errors.New("captcha: ticket already used")
+)
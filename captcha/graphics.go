@@ -0,0 +1,86 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand/v2"
+)
+
+// backgroundImage 从 backgrounds 中随机选一张并复制为可修改的图片，backgrounds 为空时随机生成
+func backgroundImage(backgrounds []image.Image, width, height int) *image.NRGBA {
+	if n := len(backgrounds); n > 0 {
+		src := backgrounds[rand.IntN(n)]
+		dst := image.NewNRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+		draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+		return dst
+	}
+	return randomBackground(width, height)
+}
+
+// randomBackground 生成随机渐变背景，并叠加半透明的圆形和矩形作为纹理
+func randomBackground(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	from, to := randomColor(), randomColor()
+	for x := 0; x < width; x++ {
+		c := blend(from, to, float64(x)/float64(width))
+		for y := 0; y < height; y++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	for n := 0; n < 12; n++ {
+		c := randomColor()
+		cx, cy := rand.IntN(width), rand.IntN(height)
+		r := 8 + rand.IntN(height/3+1)
+		rect := n%2 == 0
+		for x := max(cx-r, 0); x < min(cx+r, width); x++ {
+			for y := max(cy-r, 0); y < min(cy+r, height); y++ {
+				if rect || inCircle(x, y, cx, cy, r) {
+					img.SetNRGBA(x, y, blend(img.NRGBAAt(x, y), c, 0.35))
+				}
+			}
+		}
+	}
+	return img
+}
+
+func randomColor() color.NRGBA {
+	return color.NRGBA{
+		R: uint8(40 + rand.IntN(200)),
+		G: uint8(40 + rand.IntN(200)),
+		B: uint8(40 + rand.IntN(200)),
+		A: 255,
+	}
+}
+
+// blend 按 ratio 把 b 混合到 a 上
+func blend(a, b color.NRGBA, ratio float64) color.NRGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-ratio) + float64(y)*ratio)
+	}
+	return color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: a.A}
+}
+
+// encodePNG 把图片编码为 PNG 格式的 data URI
+func encodePNG(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return dataURI("image/png", buf.Bytes()), nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func inCircle(x, y, cx, cy, r int) bool {
+	dx, dy := x-cx, y-cy
+	return dx*dx+dy*dy <= r*r
+}
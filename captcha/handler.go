@@ -0,0 +1,287 @@
+package captcha
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linorwang/goaid/ratelimit"
+	"github.com/linorwang/goaid/resp"
+)
+
+// mediaKeyPrefix 生成的图片或语音在 CaptchaStore 中的 key 前缀
+const mediaKeyPrefix = "media:"
+
+// encodeMedia 把 MIME 类型和媒体数据保存为一个值，MIME 类型中不会出现换行符
+func encodeMedia(contentType string, data []byte) string {
+	return contentType + "\n" + string(data)
+}
+
+func decodeMedia(value string) (contentType, data string) {
+	contentType, data, _ = strings.Cut(value, "\n")
+	return contentType, data
+}
+
+type handlerOptions struct {
+	prefix  string
+	limiter ratelimit.Limiter
+	keyFunc ratelimit.KeyFunc
+	tickets *Ticketer
+	risk    *RiskPolicy
+	media   CaptchaStore
+	debug   bool
+}
+
+// HandlerOption 配置 NewHandler 创建的 HTTP 处理器
+type HandlerOption func(*handlerOptions)
+
+// WithPathPrefix 设置路由前缀，默认 "/captcha"
+func WithPathPrefix(prefix string) HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.prefix = "/" + strings.Trim(prefix, "/")
+	}
+}
+
+// WithGenerateLimiter 对生成接口按客户端限流，被限流时返回 429，默认不限流
+func WithGenerateLimiter(l ratelimit.Limiter) HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.limiter = l
+	}
+}
+
+// WithClientKey 设置识别客户端的方式，用于生成限流和票据绑定，
+// 默认使用不信任任何代理的 ratelimit.ClientIPKey
+func WithClientKey(fn ratelimit.KeyFunc) HandlerOption {
+	return func(opts *handlerOptions) {
+		if fn != nil {
+			opts.keyFunc = fn
+		}
+	}
+}
+
+// WithTicketer 校验通过后签发验证票据，票据绑定客户端 key，在校验接口的 ticket 字段返回
+func WithTicketer(t *Ticketer) HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.tickets = t
+	}
+}
+
+// WithRiskPolicy 按客户端的失败次数调整生成的验证码复杂度，校验失败时记录一次失败
+func WithRiskPolicy(p *RiskPolicy) HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.risk = p
+	}
+}
+
+// WithMediaStore 使用单独的存储保存生成的图片和语音，默认和验证码答案共用服务的存储，
+// 此时媒体数据会占用 MemoryCaptchaStore 的容量
+func WithMediaStore(store CaptchaStore) HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.media = store
+	}
+}
+
+// WithDebug 在生成接口返回验证码的值，仅用于测试，生产环境不要开启
+func WithDebug() HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.debug = true
+	}
+}
+
+// Handler 验证码 HTTP 处理器
+//
+// 提供三个接口（prefix 默认 "/captcha"）：
+//
+//	POST {prefix}/generate     生成验证码，可以用 ?type=audio 等参数选择类型
+//	GET  {prefix}/media/{id}   按 ID 输出图片或 WAV 语音
+//	POST {prefix}/verify       校验验证码，请求体为 {"id": "...", "answer": "..."}
+//
+// 挂载方式：mux.Handle("/captcha/", captcha.NewHandler(service))
+type Handler struct {
+	service *DefaultImageCaptchaService
+	opts    handlerOptions
+	mux     *http.ServeMux
+}
+
+// NewHandler 创建验证码 HTTP 处理器
+func NewHandler(service *DefaultImageCaptchaService, opts ...HandlerOption) *Handler {
+	o := handlerOptions{
+		prefix:  "/captcha",
+		keyFunc: ratelimit.ClientIPKey(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	if o.prefix == "/" {
+		o.prefix = ""
+	}
+	if o.media == nil {
+		o.media = service.store
+	}
+
+	h := &Handler{service: service, opts: o, mux: http.NewServeMux()}
+	h.mux.HandleFunc("POST "+o.prefix+"/generate", h.generate)
+	h.mux.HandleFunc("GET "+o.prefix+"/media/{id}", h.media)
+	h.mux.HandleFunc("POST "+o.prefix+"/verify", h.verify)
+	return h
+}
+
+// ServeHTTP 实现 http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) generate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := h.opts.keyFunc(r)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, "client key unavailable", nil)
+		return
+	}
+	if h.opts.limiter != nil && key != "" {
+		limited, err := h.opts.limiter.Limit(ctx, key)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, "rate limiter unavailable", nil)
+			return
+		}
+		if limited {
+			writeJSON(w, http.StatusTooManyRequests, "too many requests", nil)
+			return
+		}
+	}
+
+	// 只允许选择类型，尺寸等参数由服务端配置决定，避免客户端请求超大图片
+	var opt CaptchaOption
+	switch t := CaptchaType(r.URL.Query().Get("type")); t {
+	case "", CaptchaTypeDigit, CaptchaTypeString, CaptchaTypeMath, CaptchaTypeChinese, CaptchaTypeAudio:
+		opt.Type = t
+	default:
+		writeJSON(w, http.StatusBadRequest, "unsupported captcha type", nil)
+		return
+	}
+
+	if h.opts.risk != nil {
+		challenge, err := h.opts.risk.Evaluate(ctx, key)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, "risk policy unavailable", nil)
+			return
+		}
+		opt.Complexity = challenge.Complexity
+	}
+
+	captcha, err := h.service.GenerateCaptcha(ctx, opt)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, "generate captcha failed", nil)
+		return
+	}
+	if err = h.opts.media.Set(ctx, mediaKeyPrefix+captcha.ID, encodeMedia(captcha.ContentType, captcha.Data), time.Until(captcha.ExpireAt)); err != nil {
+		writeJSON(w, http.StatusInternalServerError, "generate captcha failed", nil)
+		return
+	}
+
+	captcha.ImageURL = h.opts.prefix + "/media/" + captcha.ID
+	if !h.opts.debug {
+		captcha.Value = ""
+	}
+	writeJSON(w, http.StatusOK, "ok", captcha)
+}
+
+func (h *Handler) media(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !isImageID(id) {
+		writeJSON(w, http.StatusNotFound, "captcha not found", nil)
+		return
+	}
+	value, err := h.opts.media.Get(r.Context(), mediaKeyPrefix+id)
+	if errors.Is(err, ErrCaptchaNotFound) {
+		writeJSON(w, http.StatusNotFound, "captcha not found", nil)
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, "captcha store unavailable", nil)
+		return
+	}
+
+	contentType, data := decodeMedia(value)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(data))
+}
+
+// verifyRequest 校验接口的请求体
+type verifyRequest struct {
+	ID     string `json:"id"`
+	Answer string `json:"answer"`
+}
+
+// verifyResult 校验接口的返回数据
+type verifyResult struct {
+	Valid  bool   `json:"valid"`
+	Ticket string `json:"ticket,omitempty"`
+}
+
+func (h *Handler) verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// 媒体数据和票据的 key 带前缀，和验证码在同一个存储中，不允许通过校验接口访问
+	var req verifyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil || !isImageID(req.ID) {
+		writeJSON(w, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	valid, err := h.service.VerifyCaptcha(ctx, req.ID, req.Answer)
+	if h.opts.risk != nil && !valid && (err == nil || errors.Is(err, ErrCaptchaNotFound)) {
+		if key, keyErr := h.opts.keyFunc(r); keyErr == nil {
+			_, _ = h.opts.risk.RecordFailure(ctx, key)
+		}
+	}
+	if errors.Is(err, ErrCaptchaNotFound) {
+		_ = h.opts.media.Delete(ctx, mediaKeyPrefix+req.ID)
+		writeJSON(w, http.StatusNotFound, "captcha not found", nil)
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, "captcha store unavailable", nil)
+		return
+	}
+
+	// 答对或者答错次数用完后验证码被删除，媒体数据也不再需要
+	if valid || h.consumed(r, req.ID) {
+		_ = h.opts.media.Delete(ctx, mediaKeyPrefix+req.ID)
+	}
+
+	result := verifyResult{Valid: valid}
+	if valid {
+
+		if h.opts.tickets != nil {
+			fingerprint, err := h.opts.keyFunc(r)
+			if err == nil {
+				result.Ticket, err = h.opts.tickets.Issue(ctx, req.ID, fingerprint)
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, "issue ticket failed", nil)
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, "ok", result)
+}
+
+// consumed 报告答错后验证码是否已经失效，存储出错时按未失效处理，媒体数据等过期后删除
+func (h *Handler) consumed(r *http.Request, id string) bool {
+	_, err := h.service.store.Get(r.Context(), id)
+	return errors.Is(err, ErrCaptchaNotFound)
+}
+
+func writeJSON(w http.ResponseWriter, status int, msg string, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp.Result{Code: status, Data: data, Msg: msg})
+}
@@ -0,0 +1,215 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linorwang/goaid/ratelimit"
+)
+
+type testResult[T any] struct {
+	Code int    `json:"code"`
+	Data T      `json:"data"`
+	Msg  string `json:"msg"`
+}
+
+func doRequest[T any](t *testing.T, h http.Handler, method, target, body string) (*httptest.ResponseRecorder, testResult[T]) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var result testResult[T]
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return rec, result
+}
+
+func TestHandlerFlow(t *testing.T) {
+	h := NewHandler(New(NewMemoryCaptchaStore()))
+
+	rec, created := doRequest[CaptchaResponse](t, h, http.MethodPost, "/captcha/generate", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("generate status = %d", rec.Code)
+	}
+	c := created.Data
+	if c.ID == "" || !strings.HasPrefix(c.ImageBase64, "data:image/png;base64,") || c.ExpireAt.IsZero() {
+		t.Fatalf("unexpected generate response: %+v", c)
+	}
+	if c.Value != "" {
+		t.Fatal("production mode must not expose the captcha value")
+	}
+	if c.ImageURL != "/captcha/media/"+c.ID {
+		t.Fatalf("unexpected image url %q", c.ImageURL)
+	}
+
+	rec, _ = doRequest[any](t, h, http.MethodGet, c.ImageURL, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("media status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec, verified := doRequest[verifyResult](t, h, http.MethodPost, "/captcha/verify", `{"id":"`+c.ID+`","answer":"wrong"}`)
+	if rec.Code != http.StatusOK || verified.Data.Valid {
+		t.Fatalf("verify wrong answer = %d, %+v", rec.Code, verified)
+	}
+
+	rec, _ = doRequest[any](t, h, http.MethodGet, "/captcha/media/unknown", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown media status = %d", rec.Code)
+	}
+	rec, _ = doRequest[any](t, h, http.MethodPost, "/captcha/verify", `not json`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid verify request status = %d", rec.Code)
+	}
+	rec, _ = doRequest[any](t, h, http.MethodPost, "/captcha/generate?type=click", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported type status = %d", rec.Code)
+	}
+}
+
+func TestHandlerDebugAndTicket(t *testing.T) {
+	store := NewMemoryCaptchaStore()
+	tickets, err := NewTicketer(testTicketSecret, store, time.Minute)
+	if err != nil {
+		t.Fatalf("NewTicketer failed: %v", err)
+	}
+	h := NewHandler(New(store), WithPathPrefix("/api/captcha/"), WithDebug(), WithTicketer(tickets))
+
+	_, created := doRequest[CaptchaResponse](t, h, http.MethodPost, "/api/captcha/generate?type=audio", "")
+	c := created.Data
+	if c.Value == "" || c.AudioBase64 == "" {
+		t.Fatalf("unexpected debug audio response: %+v", c)
+	}
+
+	rec, _ := doRequest[any](t, h, http.MethodGet, c.ImageURL, "")
+	if rec.Header().Get("Content-Type") != c.ContentType {
+		t.Fatalf("unexpected audio content type %q", rec.Header().Get("Content-Type"))
+	}
+
+	rec, verified := doRequest[verifyResult](t, h, http.MethodPost, "/api/captcha/verify", `{"id":"`+c.ID+`","answer":"`+c.Value+`"}`)
+	if rec.Code != http.StatusOK || !verified.Data.Valid || verified.Data.Ticket == "" {
+		t.Fatalf("verify = %d, %+v", rec.Code, verified)
+	}
+	if id, err := tickets.Redeem(context.Background(), verified.Data.Ticket, "10.0.0.1"); err != nil || id != c.ID {
+		t.Fatalf("Redeem = %q, %v", id, err)
+	}
+
+	// 验证成功后图片和验证码都被删除
+	rec, _ = doRequest[any](t, h, http.MethodGet, c.ImageURL, "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("media after verify status = %d", rec.Code)
+	}
+	rec, _ = doRequest[any](t, h, http.MethodPost, "/api/captcha/verify", `{"id":"`+c.ID+`","answer":"`+c.Value+`"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("second verify status = %d", rec.Code)
+	}
+}
+
+func TestHandlerGenerateLimiter(t *testing.T) {
+	limiter, err := ratelimit.NewMemoryFixedWindowLimiter(time.Minute, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(New(NewMemoryCaptchaStore()), WithGenerateLimiter(limiter))
+
+	for i := 0; i < 2; i++ {
+		if rec, _ := doRequest[any](t, h, http.MethodPost, "/captcha/generate", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d", i, rec.Code)
+		}
+	}
+	if rec, _ := doRequest[any](t, h, http.MethodPost, "/captcha/generate", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+}
+
+func TestHandlerReservedKeys(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore()
+	tickets, err := NewTicketer(testTicketSecret, store, time.Minute)
+	if err != nil {
+		t.Fatalf("NewTicketer failed: %v", err)
+	}
+	h := NewHandler(New(store, CaptchaOption{MaxAttempts: 1}), WithTicketer(tickets))
+
+	_, created := doRequest[CaptchaResponse](t, h, http.MethodPost, "/captcha/generate", "")
+	c := created.Data
+	ticket, err := tickets.Issue(ctx, c.ID, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	var ticketKey string
+	for key := range store.data {
+		if strings.HasPrefix(key, ticketKeyPrefix) {
+			ticketKey = key
+		}
+	}
+
+	// 答错一次就失效，如果能访问到这些 key，媒体数据和票据会被删除
+	for _, id := range []string{mediaKeyPrefix + c.ID, ticketKey} {
+		rec, _ := doRequest[any](t, h, http.MethodPost, "/captcha/verify", `{"id":"`+id+`","answer":"wrong"}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("verify %q status = %d", id, rec.Code)
+		}
+	}
+	if rec, _ := doRequest[any](t, h, http.MethodGet, "/captcha/media/"+ticketKey, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("media with reserved id status = %d", rec.Code)
+	}
+
+	if rec, _ := doRequest[any](t, h, http.MethodGet, c.ImageURL, ""); rec.Code != http.StatusOK {
+		t.Fatalf("media status = %d", rec.Code)
+	}
+	if _, err = tickets.Redeem(ctx, ticket, "10.0.0.1"); err != nil {
+		t.Fatalf("Redeem failed: %v", err)
+	}
+}
+
+func TestHandlerMediaCleanup(t *testing.T) {
+	store, media := NewMemoryCaptchaStore(), NewMemoryCaptchaStore()
+	h := NewHandler(New(store, CaptchaOption{MaxAttempts: 2}), WithMediaStore(media))
+
+	_, created := doRequest[CaptchaResponse](t, h, http.MethodPost, "/captcha/generate", "")
+	c := created.Data
+	verify := `{"id":"` + c.ID + `","answer":"wrong"}`
+
+	// 还有剩余次数时保留媒体数据，次数用完后一起删除
+	doRequest[any](t, h, http.MethodPost, "/captcha/verify", verify)
+	if media.Stats().Size != 1 {
+		t.Fatal("media deleted before the captcha was invalidated")
+	}
+	doRequest[any](t, h, http.MethodPost, "/captcha/verify", verify)
+	if media.Stats().Size != 0 {
+		t.Fatal("media kept after the captcha was invalidated")
+	}
+
+	// 验证码已经不存在（例如过期）时也删除媒体数据
+	_, created = doRequest[CaptchaResponse](t, h, http.MethodPost, "/captcha/generate", "")
+	if err := store.Delete(context.Background(), created.Data.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	rec, _ := doRequest[any](t, h, http.MethodPost, "/captcha/verify", `{"id":"`+created.Data.ID+`","answer":"wrong"}`)
+	if rec.Code != http.StatusNotFound || media.Stats().Size != 0 {
+		t.Fatalf("verify missing captcha = %d, media size %d", rec.Code, media.Stats().Size)
+	}
+}
+
+func TestHandlerMediaStore(t *testing.T) {
+	store, media := NewMemoryCaptchaStore(), NewMemoryCaptchaStore()
+	h := NewHandler(New(store), WithMediaStore(media))
+
+	_, created := doRequest[CaptchaResponse](t, h, http.MethodPost, "/captcha/generate", "")
+	if store.Stats().Size != 1 || media.Stats().Size != 1 {
+		t.Fatalf("expected one entry in each store, got %d and %d", store.Stats().Size, media.Stats().Size)
+	}
+	if rec, _ := doRequest[any](t, h, http.MethodGet, created.Data.ImageURL, ""); rec.Code != http.StatusOK {
+		t.Fatalf("media status = %d", rec.Code)
+	}
+}
@@ -3,18 +3,35 @@ package captcha
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
-	"image/png"
+	"image/color"
 	"strings"
 	"time"
 
 	"github.com/mojocn/base64Captcha"
 )
 
+// alphanumericSource 字母数字验证码字符集，去掉了 0/O、1/I/L 等容易混淆的字符。
+// 校验时不区分大小写，所以只保留大写字母。
+const alphanumericSource = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// chineseFont 中文验证码使用的内置字体
+const chineseFont = "wqy-microhei.ttc"
+
 // DefaultImageCaptchaService 默认图片验证码服务
 type DefaultImageCaptchaService struct {
 	store   CaptchaStore
 	options CaptchaOption
+	fonts   *fontSet // 解析后的自定义字体，没有配置时为 nil
+	fontErr error    // 自定义字体解析失败的原因，生成时返回
+}
+
+// New 创建默认图片验证码服务，options 可省略，省略时使用默认配置
+func New(store CaptchaStore, options ...CaptchaOption) *DefaultImageCaptchaService {
+	var opt CaptchaOption
+	if len(options) > 0 {
+		opt = options[0]
+	}
+	return NewDefaultImageCaptchaService(store, opt)
 }
 
 // NewDefaultImageCaptchaService 创建默认图片验证码服务
@@ -31,104 +48,250 @@ func NewDefaultImageCaptchaService(store CaptchaStore, options CaptchaOption) *D
 	if options.Height == 0 {
 		options.Height = 40 // 默认高度
 	}
+	if options.Complexity == 0 {
+		options.Complexity = ComplexityMedium
+	}
+	if options.Type == "" {
+		options.Type = CaptchaTypeDigit
+	}
+	if options.Language == "" {
+		options.Language = "en"
+	}
+	if options.MaxAttempts == 0 {
+		options.MaxAttempts = 3 // 默认最多答错3次
+	}
 
-	return &DefaultImageCaptchaService{
+	if options.Format == "" {
+		options.Format = FormatPNG
+	}
+
+	s := &DefaultImageCaptchaService{
 		store:   store,
 		options: options,
 	}
+	s.fonts, s.fontErr = parseFonts(options.Fonts)
+	return s
 }
 
-// GenerateImageCaptcha 生成图片验证码
+// GenerateImageCaptcha 生成图片验证码，类型使用服务配置的 Type
 func (s *DefaultImageCaptchaService) GenerateImageCaptcha(ctx context.Context, width, height int) (*CaptchaResponse, error) {
-	if width == 0 {
-		width = s.options.Width
-	}
-	if height == 0 {
-		height = s.options.Height
-	}
-
-	// 创建数字验证码驱动
-	driver := base64Captcha.NewDriverDigit(height, width, s.options.Length, 0.7, 80)
-
-	// 创建验证码
-	captcha := base64Captcha.NewCaptcha(driver, nil)
+	return s.GenerateCaptcha(ctx, CaptchaOption{Width: width, Height: height})
+}
 
-	// 生成验证码
-	idKey, content, _ := captcha.Driver.GenerateIdQuestionAnswer()
-	item, err := captcha.Driver.DrawCaptcha(content)
+// GenerateCaptcha 按本次调用的配置生成验证码，opt 中的零值字段使用服务的默认配置。
+// 可以通过 opt.Type 为单次请求选择验证码类型，例如为视障用户返回语音验证码。
+func (s *DefaultImageCaptchaService) GenerateCaptcha(ctx context.Context, opt CaptchaOption) (*CaptchaResponse, error) {
+	fonts, err := s.fontsFor(opt)
 	if err != nil {
 		return nil, err
 	}
+	opt = s.merge(opt)
 
-	// 将图片转换为base64字符串
-	b64s := item.EncodeB64string()
-		
-	// 解码base64为图片字节
-	// 移除base64字符串中的前缀部分，如 "data:image/png;base64," 
-	if idx := strings.Index(b64s, ","); idx != -1 {
-		b64s = b64s[idx+1:]
-	}
-		
-	imgData, err := base64.StdEncoding.DecodeString(b64s)
+	driver, err := newDriver(opt, fonts)
 	if err != nil {
 		return nil, err
 	}
-		
-	// 创建图片
-	img, err := png.Decode(bytes.NewReader(imgData))
+
+	// 算术验证码的题目（content）和答案（answer）不同，存储的是答案
+	id, content, answer := driver.GenerateIdQuestionAnswer()
+	item, err := driver.DrawCaptcha(content)
 	if err != nil {
 		return nil, err
 	}
 
+	response := &CaptchaResponse{
+		ID:       id,
+		Type:     opt.Type,
+		ExpireAt: time.Now().Add(opt.ExpireTime),
+		// 注意：在生产环境中，不应返回Value字段，这里仅用于演示
+		Value: answer,
+	}
+	if opt.Type == CaptchaTypeAudio {
+		var buf bytes.Buffer
+		if _, err = item.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+		response.Data = buf.Bytes()
+		response.ContentType = base64Captcha.MimeTypeAudio
+		response.AudioBase64 = dataURI(response.ContentType, response.Data)
+	} else {
+		if response.Image, response.Data, err = renderImage(item, opt); err != nil {
+			return nil, err
+		}
+		response.ContentType = opt.Format.contentType()
+		response.ImageBase64 = dataURI(response.ContentType, response.Data)
+	}
+
 	// 存储验证码到存储器
-	err = s.store.Set(ctx, idKey, content, s.options.ExpireTime)
-	if err != nil {
+	if err = s.store.Set(ctx, id, normalizeAnswer(answer), opt.ExpireTime); err != nil {
 		return nil, err
 	}
 
-	// 将图片转换为base64字符串
-	var buf bytes.Buffer
-	err = png.Encode(&buf, img)
-	if err != nil {
-		return nil, err
+	return response, nil
+}
+
+// VerifyCaptcha 验证验证码
+//
+// 校验由存储原子完成：答对后验证码立即删除，并发提交同一个正确答案时只有一个会成功；
+// 答错累计达到 MaxAttempts 次后验证码失效，之后再校验返回 ErrCaptchaNotFound。
+// 其他类型验证码的 ID 不能用于图片验证码校验。
+func (s *DefaultImageCaptchaService) VerifyCaptcha(ctx context.Context, id, answer string) (bool, error) {
+	if !isImageID(id) {
+		return false, ErrCaptchaNotFound
+	}
+	maxAttempts := s.options.MaxAttempts
+	if maxAttempts < 0 {
+		maxAttempts = 0
 	}
-	imgBytes := buf.Bytes()
-	base64Str := base64.StdEncoding.EncodeToString(imgBytes)
+	return s.store.Verify(ctx, id, normalizeAnswer(answer), maxAttempts)
+}
 
-	expireAt := time.Now().Add(s.options.ExpireTime)
+// DeleteCaptcha 删除验证码
+func (s *DefaultImageCaptchaService) DeleteCaptcha(ctx context.Context, id string) error {
+	if !isImageID(id) {
+		return ErrCaptchaNotFound
+	}
+	return s.store.Delete(ctx, id)
+}
 
-	response := &CaptchaResponse{
-		ID:        idKey,
-		Image:     img,
-		ImageBase64: "data:image/png;base64," + base64Str,
-		ExpireAt:  expireAt,
-		// 注意：在生产环境中，不应返回Value字段，这里仅用于演示
-		Value:     content,
+// merge 用服务配置补齐单次调用配置中的零值字段
+func (s *DefaultImageCaptchaService) merge(opt CaptchaOption) CaptchaOption {
+	if opt.ExpireTime == 0 {
+		opt.ExpireTime = s.options.ExpireTime
+	}
+	if opt.Length == 0 {
+		opt.Length = s.options.Length
+	}
+	if opt.Width == 0 {
+		opt.Width = s.options.Width
+	}
+	if opt.Height == 0 {
+		opt.Height = s.options.Height
+	}
+	if opt.Complexity == 0 {
+		opt.Complexity = s.options.Complexity
+	}
+	if opt.Type == "" {
+		opt.Type = s.options.Type
 	}
+	if opt.Language == "" {
+		opt.Language = s.options.Language
+	}
+	if len(opt.Backgrounds) == 0 {
+		opt.Backgrounds = s.options.Backgrounds
+	}
+	if opt.BgColor == nil {
+		opt.BgColor = s.options.BgColor
+	}
+	if opt.NoiseCount == 0 {
+		opt.NoiseCount = s.options.NoiseCount
+	}
+	if opt.LineOptions == 0 {
+		opt.LineOptions = s.options.LineOptions
+	}
+	if opt.Format == "" {
+		opt.Format = s.options.Format
+	}
+	return opt
+}
 
-	return response, nil
+// fontsFor 返回本次调用使用的字体，单次调用指定了 Fonts 时临时解析，否则使用服务创建时解析好的字体
+func (s *DefaultImageCaptchaService) fontsFor(opt CaptchaOption) (*fontSet, error) {
+	if len(opt.Fonts) > 0 {
+		return parseFonts(opt.Fonts)
+	}
+	return s.fonts, s.fontErr
 }
 
-// VerifyCaptcha 验证验证码
-func (s *DefaultImageCaptchaService) VerifyCaptcha(ctx context.Context, id, answer string) (bool, error) {
-	// 获取存储的验证码
-	storedAnswer, err := s.store.Get(ctx, id)
-	if err != nil {
-		return false, err
+// noiseLevel 复杂度对应的干扰参数
+type noiseLevel struct {
+	maxSkew    float64 // 数字验证码的最大扭曲程度
+	dotCount   int     // 数字验证码的干扰点数量
+	noiseCount int     // 字符验证码的干扰字符数量
+	lineOpts   int     // 字符验证码的干扰线
+}
+
+func levelOf(complexity int) noiseLevel {
+	switch {
+	case complexity <= ComplexityLow:
+		return noiseLevel{
+			maxSkew:    0.4,
+			dotCount:   40,
+			noiseCount: 0,
+			lineOpts:   base64Captcha.OptionShowSlimeLine,
+		}
+	case complexity == ComplexityMedium:
+		return noiseLevel{
+			maxSkew:    0.7,
+			dotCount:   80,
+			noiseCount: 2,
+			lineOpts:   base64Captcha.OptionShowSlimeLine | base64Captcha.OptionShowHollowLine,
+		}
+	default:
+		return noiseLevel{
+			maxSkew:    0.9,
+			dotCount:   120,
+			noiseCount: 5,
+			lineOpts:   base64Captcha.OptionShowSlimeLine | base64Captcha.OptionShowHollowLine | base64Captcha.OptionShowSineLine,
+		}
 	}
+}
 
-	// 验证答案是否正确
-	isValid := strings.ToLower(storedAnswer) == strings.ToLower(answer)
+// newDriver 根据验证码类型和复杂度创建 base64Captcha 驱动
+func newDriver(opt CaptchaOption, fonts *fontSet) (base64Captcha.Driver, error) {
+	level := levelOf(opt.Complexity)
+	if opt.NoiseCount > 0 {
+		level.dotCount = opt.NoiseCount
+		level.noiseCount = opt.NoiseCount
+	}
+	if opt.LineOptions > 0 {
+		level.lineOpts = opt.LineOptions
+	}
 
-	if isValid {
-		// 验证成功后删除验证码（防止重复使用）
-		s.store.Delete(ctx, id)
+	// 有背景图时字符画在透明底上，再和背景图合成
+	bgColor := opt.BgColor
+	if len(opt.Backgrounds) > 0 {
+		bgColor = &color.RGBA{}
 	}
 
-	return isValid, nil
+	var (
+		storage base64Captcha.FontsStorage
+		names   []string
+	)
+	if fonts != nil {
+		storage, names = fonts, fonts.names
+	}
+
+	switch opt.Type {
+	case CaptchaTypeDigit:
+		return base64Captcha.NewDriverDigit(opt.Height, opt.Width, opt.Length, level.maxSkew, level.dotCount), nil
+	case CaptchaTypeString:
+		return base64Captcha.NewDriverString(opt.Height, opt.Width, level.noiseCount, level.lineOpts,
+			opt.Length, alphanumericSource, bgColor, storage, names), nil
+	case CaptchaTypeMath:
+		return base64Captcha.NewDriverMath(opt.Height, opt.Width, level.noiseCount, level.lineOpts,
+			bgColor, storage, names), nil
+	case CaptchaTypeChinese:
+		if names == nil {
+			names = []string{chineseFont}
+		}
+		return base64Captcha.NewDriverChinese(opt.Height, opt.Width, level.noiseCount, level.lineOpts,
+			opt.Length, base64Captcha.TxtChineseCharaters, bgColor, storage, names), nil
+	case CaptchaTypeAudio:
+		return base64Captcha.NewDriverAudio(opt.Length, opt.Language), nil
+	default:
+		return nil, ErrUnsupportedType
+	}
 }
 
-// DeleteCaptcha 删除验证码
-func (s *DefaultImageCaptchaService) DeleteCaptcha(ctx context.Context, id string) error {
-	return s.store.Delete(ctx, id)
-}
\ No newline at end of file
+// isImageID 判断 id 能否作为图片验证码的 key。
+// 其他类型的验证码、票据和媒体数据在 CaptchaStore 中的 key 都带 "xxx:" 前缀，
+// 图片验证码不能通过这些 key 校验或删除它们
+func isImageID(id string) bool {
+	return id != "" && !strings.Contains(id, ":")
+}
+
+// normalizeAnswer 统一答案格式，校验时忽略首尾空格和大小写
+func normalizeAnswer(answer string) string {
+	return strings.ToLower(strings.TrimSpace(answer))
+}
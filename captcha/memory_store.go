@@ -1,30 +1,88 @@
 package captcha
 
 import (
+	"container/list"
 	"context"
 	"sync"
 	"time"
 )
 
 type memoryCaptchaItem struct {
+	id       string
 	value    string
 	expireAt time.Time
+	attempts int
+}
+
+// MemoryStoreOption configures a MemoryCaptchaStore.
+type MemoryStoreOption func(*MemoryCaptchaStore)
+
+// WithSweepInterval starts a background goroutine that removes expired
+// captchas every d; call Close to stop it. Without this option, or with zero
+// or a negative value, no goroutine is started and expired captchas are only
+// removed when accessed, evicted, or swept with Sweep.
+func WithSweepInterval(d time.Duration) MemoryStoreOption {
+	return func(m *MemoryCaptchaStore) {
+		m.sweepInterval = d
+	}
+}
+
+// WithMaxEntries limits the number of stored captchas. When the limit is
+// reached, the oldest captcha is evicted to make room. The default is 100000;
+// zero or a negative value means unlimited.
+func WithMaxEntries(n int) MemoryStoreOption {
+	return func(m *MemoryCaptchaStore) {
+		m.maxEntries = n
+	}
+}
+
+// MemoryStoreStats reports the state of a MemoryCaptchaStore.
+type MemoryStoreStats struct {
+	Size        int    // current number of entries, including expired ones not yet swept
+	Evictions   uint64 // entries removed because the store was full
+	Expirations uint64 // entries removed because they expired
 }
 
 // MemoryCaptchaStore stores captchas in memory.
 //
-// It is useful for tests, local demos, and single-process services. For
-// multi-instance production services, prefer RedisCaptchaStore.
+// It is useful for tests, local demos, and single-instance services. The
+// number of entries is bounded, so a flood of generation requests cannot grow
+// memory without limit; use WithSweepInterval to also remove expired
+// captchas in the background. For multi-instance production services, prefer
+// RedisCaptchaStore.
 type MemoryCaptchaStore struct {
-	mu   sync.RWMutex
-	data map[string]memoryCaptchaItem
+	mu    sync.Mutex
+	data  map[string]*list.Element
+	order *list.List // insertion order, oldest at the front
+
+	maxEntries    int
+	sweepInterval time.Duration
+	evictions     uint64
+	expirations   uint64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	now       func() time.Time
 }
 
 // NewMemoryCaptchaStore creates an in-memory captcha store.
-func NewMemoryCaptchaStore() *MemoryCaptchaStore {
-	return &MemoryCaptchaStore{
-		data: make(map[string]memoryCaptchaItem),
+func NewMemoryCaptchaStore(opts ...MemoryStoreOption) *MemoryCaptchaStore {
+	m := &MemoryCaptchaStore{
+		data:       make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: 100000,
+		stop:       make(chan struct{}),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	if m.sweepInterval > 0 {
+		go m.sweepLoop()
 	}
+	return m
 }
 
 // Set stores a captcha value.
@@ -36,10 +94,29 @@ func (m *MemoryCaptchaStore) Set(ctx context.Context, id string, value string, e
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.data[id] = memoryCaptchaItem{
+	item := &memoryCaptchaItem{
+		id:       id,
 		value:    value,
-		expireAt: time.Now().Add(expire),
+		expireAt: m.now().Add(expire),
+	}
+	if el, ok := m.data[id]; ok {
+		el.Value = item
+		m.order.MoveToBack(el)
+		return nil
+	}
+
+	if m.maxEntries > 0 {
+		for m.order.Len() >= m.maxEntries {
+			oldest := m.order.Front()
+			if m.expired(oldest.Value.(*memoryCaptchaItem)) {
+				m.expirations++
+			} else {
+				m.evictions++
+			}
+			m.remove(oldest)
+		}
 	}
+	m.data[id] = m.order.PushBack(item)
 	return nil
 }
 
@@ -49,18 +126,13 @@ func (m *MemoryCaptchaStore) Get(ctx context.Context, id string) (string, error)
 		return "", err
 	}
 
-	m.mu.RLock()
-	item, ok := m.data[id]
-	m.mu.RUnlock()
-	if !ok {
-		return "", ErrCaptchaNotFound
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	if time.Now().After(item.expireAt) {
-		_ = m.Delete(ctx, id)
+	item, ok := m.lookup(id)
+	if !ok {
 		return "", ErrCaptchaNotFound
 	}
-
 	return item.value, nil
 }
 
@@ -73,6 +145,132 @@ func (m *MemoryCaptchaStore) Delete(ctx context.Context, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.data, id)
+	if el, ok := m.data[id]; ok {
+		m.remove(el)
+	}
 	return nil
 }
+
+// Take atomically returns and removes a captcha value.
+func (m *MemoryCaptchaStore) Take(ctx context.Context, id string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(id)
+	if !ok {
+		return "", ErrCaptchaNotFound
+	}
+	m.remove(m.data[id])
+	return item.value, nil
+}
+
+// Verify atomically compares answer with the stored value. A match removes the
+// captcha; a mismatch is counted and the captcha is removed once maxAttempts
+// wrong answers have been submitted.
+func (m *MemoryCaptchaStore) Verify(ctx context.Context, id, answer string, maxAttempts int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(id)
+	if !ok {
+		return false, ErrCaptchaNotFound
+	}
+
+	if item.value == answer {
+		m.remove(m.data[id])
+		return true, nil
+	}
+
+	item.attempts++
+	if maxAttempts > 0 && item.attempts >= maxAttempts {
+		m.remove(m.data[id])
+	}
+	return false, nil
+}
+
+// Stats returns the current size and the eviction and expiration counters.
+func (m *MemoryCaptchaStore) Stats() MemoryStoreStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return MemoryStoreStats{
+		Size:        len(m.data),
+		Evictions:   m.evictions,
+		Expirations: m.expirations,
+	}
+}
+
+// Close stops the background sweeper started by WithSweepInterval. The store
+// remains usable afterwards, but expired captchas are then only removed when
+// accessed or evicted.
+func (m *MemoryCaptchaStore) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+	})
+	return nil
+}
+
+// Sweep removes all expired captchas and returns how many were removed.
+func (m *MemoryCaptchaStore) Sweep() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for el := m.order.Front(); el != nil; {
+		next := el.Next()
+		if m.expired(el.Value.(*memoryCaptchaItem)) {
+			m.remove(el)
+			removed++
+		}
+		el = next
+	}
+	m.expirations += uint64(removed)
+	return removed
+}
+
+func (m *MemoryCaptchaStore) sweepLoop() {
+	ticker := time.NewTicker(m.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Sweep()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// lookup returns the live item for id, removing it if it has expired.
+// The caller must hold m.mu.
+func (m *MemoryCaptchaStore) lookup(id string) (*memoryCaptchaItem, bool) {
+	el, ok := m.data[id]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*memoryCaptchaItem)
+	if m.expired(item) {
+		m.remove(el)
+		m.expirations++
+		return nil, false
+	}
+	return item, true
+}
+
+func (m *MemoryCaptchaStore) expired(item *memoryCaptchaItem) bool {
+	return m.now().After(item.expireAt)
+}
+
+func (m *MemoryCaptchaStore) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.data, el.Value.(*memoryCaptchaItem).id)
+}
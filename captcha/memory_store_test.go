@@ -0,0 +1,82 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore(WithMaxEntries(3))
+
+	for i := 0; i < 5; i++ {
+		if err := store.Set(ctx, strconv.Itoa(i), "v", time.Minute); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	stats := store.Stats()
+	if stats.Size != 3 || stats.Evictions != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	// 最早的验证码被淘汰
+	if _, err := store.Get(ctx, "0"); !errors.Is(err, ErrCaptchaNotFound) {
+		t.Fatalf("expected oldest entry to be evicted, got %v", err)
+	}
+	if _, err := store.Get(ctx, "4"); err != nil {
+		t.Fatalf("expected newest entry to remain, got %v", err)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	_ = store.Set(ctx, "short", "v", time.Second)
+	_ = store.Set(ctx, "long", "v", time.Hour)
+
+	now = now.Add(time.Minute)
+	if removed := store.Sweep(); removed != 1 {
+		t.Fatalf("expected 1 expired entry, got %d", removed)
+	}
+	stats := store.Stats()
+	if stats.Size != 1 || stats.Expirations != 1 || stats.Evictions != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMemoryStoreBackgroundSweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore(WithSweepInterval(10 * time.Millisecond))
+	defer store.Close()
+
+	_ = store.Set(ctx, "id", "v", time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for store.Stats().Size != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired captcha was not swept in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+}
+
+func TestMemoryStoreNoSweeperByDefault(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		NewMemoryCaptchaStore()
+	}
+	if n := runtime.NumGoroutine(); n >= before+10 {
+		t.Fatalf("goroutines = %d, was %d before creating stores without WithSweepInterval", n, before)
+	}
+}
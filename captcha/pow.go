@@ -0,0 +1,146 @@
+package captcha
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+// PowAlgorithm 工作量证明使用的哈希算法
+const PowAlgorithm = "SHA-256"
+
+// maxPowSolutionLen 提交的 solution 最大长度，避免对超长输入做哈希
+const maxPowSolutionLen = 64
+
+// powKeyPrefix 工作量证明题目在 CaptchaStore 中的 key 前缀，
+// 和图片验证码分开，避免用题目 ID 和 Nonce 通过图片验证码校验
+const powKeyPrefix = "pow:"
+
+// DefaultPowCaptchaService 默认工作量证明验证码服务
+//
+// 不需要用户交互：服务端下发随机 Nonce 和难度，客户端（浏览器 JS 或 API 客户端）
+// 暴力搜索满足前导零要求的 solution 后提交。难度每加 1，客户端平均计算量翻倍，
+// 而服务端校验只需要一次哈希。适合 API 调用方和无障碍场景，也可以和限流配合，
+// 对请求频繁的客户端下发更高难度。每个题目只能校验一次。
+type DefaultPowCaptchaService struct {
+	store   CaptchaStore
+	options PowOption
+}
+
+// NewPowCaptchaService 创建工作量证明验证码服务
+func NewPowCaptchaService(store CaptchaStore, options PowOption) *DefaultPowCaptchaService {
+	if options.ExpireTime == 0 {
+		options.ExpireTime = 2 * time.Minute // 默认2分钟过期
+	}
+	if options.Difficulty == 0 {
+		options.Difficulty = 18 // 普通设备约需几十到几百毫秒
+	}
+	if options.MaxDifficulty == 0 {
+		options.MaxDifficulty = 32
+	}
+
+	return &DefaultPowCaptchaService{
+		store:   store,
+		options: options,
+	}
+}
+
+// GeneratePow 生成工作量证明题目，difficulty 为 0 时使用配置的默认难度，
+// 小于 0 或超过 MaxDifficulty 时返回 ErrInvalidOption
+func (s *DefaultPowCaptchaService) GeneratePow(ctx context.Context, difficulty int) (*PowChallenge, error) {
+	if difficulty == 0 {
+		difficulty = s.options.Difficulty
+	}
+	if difficulty < 0 || difficulty > s.options.MaxDifficulty || difficulty > sha256.Size*8 {
+		return nil, ErrInvalidOption
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	nonce := hex.EncodeToString(b[:])
+
+	// 难度随题目一起保存，客户端无法通过篡改难度降低计算量
+	id := base64Captcha.RandomId()
+	if err := s.store.Set(ctx, powKeyPrefix+id, strconv.Itoa(difficulty)+":"+nonce, s.options.ExpireTime); err != nil {
+		return nil, err
+	}
+
+	return &PowChallenge{
+		ID:         id,
+		Algorithm:  PowAlgorithm,
+		Nonce:      nonce,
+		Difficulty: difficulty,
+		ExpireAt:   time.Now().Add(s.options.ExpireTime),
+	}, nil
+}
+
+// VerifyPow 校验 solution
+//
+// 无论校验是否通过，题目都会被删除，失败后需要重新生成。
+func (s *DefaultPowCaptchaService) VerifyPow(ctx context.Context, id, solution string) (bool, error) {
+	stored, err := s.store.Take(ctx, powKeyPrefix+id)
+	if err != nil {
+		return false, err
+	}
+	d, nonce, ok := strings.Cut(stored, ":")
+	if !ok {
+		return false, nil
+	}
+	difficulty, err := strconv.Atoi(d)
+	if err != nil {
+		return false, nil
+	}
+	if solution == "" || len(solution) > maxPowSolutionLen {
+		return false, nil
+	}
+	return powLeadingZeros(nonce, solution) >= difficulty, nil
+}
+
+// SolvePow 求解工作量证明题目，返回满足难度要求的 solution
+//
+// 供 Go 编写的 API 客户端和测试使用，solution 为十进制计数器。
+// ctx 取消时返回 ctx.Err()。
+func SolvePow(ctx context.Context, challenge *PowChallenge) (string, error) {
+	if challenge.Algorithm != PowAlgorithm {
+		return "", ErrUnsupportedType
+	}
+	for i := uint64(0); ; i++ {
+		if i&0xffff == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		solution := strconv.FormatUint(i, 10)
+		if powLeadingZeros(challenge.Nonce, solution) >= challenge.Difficulty {
+			return solution, nil
+		}
+	}
+}
+
+// powLeadingZeros 返回 SHA-256(nonce + solution) 的前导零比特数
+func powLeadingZeros(nonce, solution string) int {
+	h := sha256.New()
+	h.Write([]byte(nonce))
+	h.Write([]byte(solution))
+	return leadingZeroBits(h.Sum(nil))
+}
+
+func leadingZeroBits(sum []byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,142 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestPowCaptcha(t *testing.T) {
+	ctx := context.Background()
+	service := NewPowCaptchaService(NewMemoryCaptchaStore(), PowOption{Difficulty: 8})
+
+	challenge, err := service.GeneratePow(ctx, 0)
+	if err != nil {
+		t.Fatalf("GeneratePow failed: %v", err)
+	}
+	if challenge.Difficulty != 8 || challenge.Algorithm != PowAlgorithm || len(challenge.Nonce) != 32 {
+		t.Fatalf("unexpected challenge: %+v", challenge)
+	}
+
+	solution, err := SolvePow(ctx, challenge)
+	if err != nil {
+		t.Fatalf("SolvePow failed: %v", err)
+	}
+	ok, err := service.VerifyPow(ctx, challenge.ID, solution)
+	if err != nil || !ok {
+		t.Fatalf("VerifyPow = %v, %v", ok, err)
+	}
+
+	// 每个题目只能校验一次
+	if _, err = service.VerifyPow(ctx, challenge.ID, solution); !errors.Is(err, ErrCaptchaNotFound) {
+		t.Fatalf("expected ErrCaptchaNotFound, got %v", err)
+	}
+}
+
+func TestPowCaptchaNotImageCaptcha(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore()
+	pow := NewPowCaptchaService(store, PowOption{Difficulty: 8})
+	image := New(store)
+
+	challenge, err := pow.GeneratePow(ctx, 0)
+	if err != nil {
+		t.Fatalf("GeneratePow failed: %v", err)
+	}
+
+	// 题目 ID、Nonce 和难度都会下发给客户端，不能直接拿来通过图片验证码
+	answer := strconv.Itoa(challenge.Difficulty) + ":" + challenge.Nonce
+	for _, id := range []string{challenge.ID, powKeyPrefix + challenge.ID} {
+		if ok, err := image.VerifyCaptcha(ctx, id, answer); ok || !errors.Is(err, ErrCaptchaNotFound) {
+			t.Fatalf("VerifyCaptcha(%q) = %v, %v, want ErrCaptchaNotFound", id, ok, err)
+		}
+	}
+
+	// 图片验证码的失败尝试不会影响题目
+	solution, err := SolvePow(ctx, challenge)
+	if err != nil {
+		t.Fatalf("SolvePow failed: %v", err)
+	}
+	if ok, err := pow.VerifyPow(ctx, challenge.ID, solution); !ok || err != nil {
+		t.Fatalf("VerifyPow = %v, %v", ok, err)
+	}
+}
+
+func TestPowCaptchaRejects(t *testing.T) {
+	ctx := context.Background()
+	service := NewPowCaptchaService(NewMemoryCaptchaStore(), PowOption{Difficulty: 8})
+
+	tests := []struct {
+		name     string
+		solution func(challenge *PowChallenge) string
+	}{
+		{name: "empty", solution: func(*PowChallenge) string { return "" }},
+		{name: "not enough work", solution: func(challenge *PowChallenge) string {
+			for i := 0; ; i++ {
+				if s := strconv.Itoa(i); powLeadingZeros(challenge.Nonce, s) < challenge.Difficulty {
+					return s
+				}
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge, err := service.GeneratePow(ctx, 0)
+			if err != nil {
+				t.Fatalf("GeneratePow failed: %v", err)
+			}
+			ok, err := service.VerifyPow(ctx, challenge.ID, tt.solution(challenge))
+			if err != nil || ok {
+				t.Fatalf("VerifyPow = %v, %v; want rejection", ok, err)
+			}
+		})
+	}
+}
+
+func TestPowCaptchaDifficulty(t *testing.T) {
+	ctx := context.Background()
+	service := NewPowCaptchaService(NewMemoryCaptchaStore(), PowOption{MaxDifficulty: 20})
+
+	challenge, err := service.GeneratePow(ctx, 12)
+	if err != nil {
+		t.Fatalf("GeneratePow failed: %v", err)
+	}
+	solution, err := SolvePow(ctx, challenge)
+	if err != nil {
+		t.Fatalf("SolvePow failed: %v", err)
+	}
+	if n := powLeadingZeros(challenge.Nonce, solution); n < 12 {
+		t.Fatalf("expected at least 12 leading zero bits, got %d", n)
+	}
+
+	for _, difficulty := range []int{-1, 21} {
+		if _, err = service.GeneratePow(ctx, difficulty); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("difficulty %d: expected ErrInvalidOption, got %v", difficulty, err)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = SolvePow(cancelled, &PowChallenge{Algorithm: PowAlgorithm, Difficulty: 256}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		sum  []byte
+		want int
+	}{
+		{sum: []byte{0x80}, want: 0},
+		{sum: []byte{0x01}, want: 7},
+		{sum: []byte{0x00, 0x10}, want: 11},
+		{sum: []byte{0x00, 0x00}, want: 16},
+	}
+	for _, tt := range tests {
+		if got := leadingZeroBits(tt.sum); got != tt.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.sum, got, tt.want)
+		}
+	}
+}
@@ -2,12 +2,19 @@ package captcha
 
 import (
 	"context"
+	_ "embed"
 	"errors"
 	"time"
 
+	"github.com/linorwang/goaid/internal/hashtag"
 	"github.com/redis/go-redis/v9"
 )
 
+//go:embed verify.lua
+var luaVerify string
+
+var verifyScript = redis.NewScript(luaVerify)
+
 // RedisCaptchaStore stores captcha answers in Redis.
 type RedisCaptchaStore struct {
 	client redis.Cmdable
@@ -52,9 +59,45 @@ func (r *RedisCaptchaStore) Get(ctx context.Context, id string) (string, error)
 
 // Delete removes a captcha answer.
 func (r *RedisCaptchaStore) Delete(ctx context.Context, id string) error {
-	return r.client.Del(ctx, r.key(id)).Err()
+	key := r.key(id)
+	return r.client.Del(ctx, key, attemptsKey(key)).Err()
+}
+
+// Take atomically returns and removes a captcha answer using GETDEL, so a
+// captcha can be consumed only once. It requires Redis 6.2 or later.
+func (r *RedisCaptchaStore) Take(ctx context.Context, id string) (string, error) {
+	value, err := r.client.GetDel(ctx, r.key(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCaptchaNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Verify atomically compares answer with the stored value. A match removes the
+// captcha; a mismatch increments a wrong-answer counter that shares the
+// captcha's TTL and removes the captcha once maxAttempts is reached.
+func (r *RedisCaptchaStore) Verify(ctx context.Context, id, answer string, maxAttempts int) (bool, error) {
+	key := r.key(id)
+	keys := []string{key, attemptsKey(key)}
+	res, err := verifyScript.Run(ctx, r.client, keys, answer, maxAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+	if res < 0 {
+		return false, ErrCaptchaNotFound
+	}
+	return res == 1, nil
 }
 
 func (r *RedisCaptchaStore) key(id string) string {
 	return r.prefix + id
 }
+
+// attemptsKey returns the wrong-answer counter key for key, in the same
+// Redis Cluster slot as key (see hashtag.SameSlot).
+func attemptsKey(key string) string {
+	return hashtag.SameSlot(key, ":attempts")
+}
@@ -0,0 +1,22 @@
+package captcha
+
+import (
+	"testing"
+
+	"github.com/linorwang/goaid/internal/hashtag"
+)
+
+func TestRedisAttemptsKey(t *testing.T) {
+	store := NewRedisCaptchaStore(nil, "")
+
+	// ID 来自客户端，可以包含任意字符
+	for _, id := range []string{"abc123", "{tenant}abc", "a{}b", "a}b{", "}"} {
+		key := store.key(id)
+		if got := attemptsKey(key); hashtag.Slot(got) != hashtag.Slot(key) || got == key {
+			t.Errorf("attemptsKey(%q) = %q is in slot %d, want %d", key, got, hashtag.Slot(got), hashtag.Slot(key))
+		}
+	}
+	if got := attemptsKey("captcha:abc"); got != "{captcha:abc}:attempts" {
+		t.Fatalf("attemptsKey = %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/mojocn/base64Captcha"
+	xdraw "golang.org/x/image/draw"
+)
+
+// 干扰线类型，可以组合使用，例如 LineSlime | LineSine
+const (
+	LineHollow = base64Captcha.OptionShowHollowLine // 空心曲线
+	LineSlime  = base64Captcha.OptionShowSlimeLine  // 细线
+	LineSine   = base64Captcha.OptionShowSineLine   // 正弦曲线
+)
+
+// ImageFormat 图片输出格式
+type ImageFormat string
+
+const (
+	FormatPNG  ImageFormat = "png"  // 默认格式，支持透明背景
+	FormatJPEG ImageFormat = "jpeg" // 体积小，透明部分会填充为白色
+	FormatGIF  ImageFormat = "gif"
+)
+
+func (f ImageFormat) contentType() string {
+	switch f {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatGIF:
+		return "image/gif"
+	default:
+		return "image/png"
+	}
+}
+
+// fontSet 自定义字体集合，实现 base64Captcha.FontsStorage
+type fontSet struct {
+	fonts map[string]*truetype.Font
+	names []string
+}
+
+// parseFonts 解析 TTF/TTC 字体文件内容，fonts 为空时返回 nil
+func parseFonts(fonts [][]byte) (*fontSet, error) {
+	if len(fonts) == 0 {
+		return nil, nil
+	}
+	set := &fontSet{fonts: make(map[string]*truetype.Font, len(fonts))}
+	for i, data := range fonts {
+		f, err := truetype.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		name := "custom-" + strconv.Itoa(i)
+		set.fonts[name] = f
+		set.names = append(set.names, name)
+	}
+	return set, nil
+}
+
+// LoadFontByName 实现 base64Captcha.FontsStorage，驱动会给名字加上 "fonts/" 前缀
+func (s *fontSet) LoadFontByName(name string) *truetype.Font {
+	return s.fonts[strings.TrimPrefix(name, "fonts/")]
+}
+
+// LoadFontsByNames 实现 base64Captcha.FontsStorage
+func (s *fontSet) LoadFontsByNames(names []string) []*truetype.Font {
+	fonts := make([]*truetype.Font, 0, len(names))
+	for _, name := range names {
+		if f := s.LoadFontByName(name); f != nil {
+			fonts = append(fonts, f)
+		}
+	}
+	return fonts
+}
+
+// renderImage 按配置合成背景并编码图片，返回图片和编码后的数据
+//
+// 不需要合成背景并且输出 PNG 时，直接使用 base64Captcha 编码好的数据，不再重新编码。
+func renderImage(item base64Captcha.Item, opt CaptchaOption) (image.Image, []byte, error) {
+	compose := len(opt.Backgrounds) > 0 || (opt.Type == CaptchaTypeDigit && opt.BgColor != nil)
+
+	var buf bytes.Buffer
+	img, isImage := item.(image.Image)
+	if !isImage || (!compose && opt.Format == FormatPNG) {
+		if _, err := item.WriteTo(&buf); err != nil {
+			return nil, nil, err
+		}
+	}
+	if digit, ok := item.(*base64Captcha.ItemDigit); ok {
+		img = clearPalette(digit.Paletted)
+	}
+	if !isImage {
+		var err error
+		if img, err = png.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !compose && opt.Format == FormatPNG {
+		return img, buf.Bytes(), nil
+	}
+
+	if compose {
+		img = composeBackground(img, opt)
+	} else if opt.Format == FormatJPEG {
+		img = flatten(img, color.White)
+	}
+
+	buf.Reset()
+	var err error
+	switch opt.Format {
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	case FormatGIF:
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return nil, nil, ErrInvalidOption
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, buf.Bytes(), nil
+}
+
+// composeBackground 把验证码画到背景上，背景图会缩放到验证码尺寸，没有背景图时使用背景色
+func composeBackground(fg image.Image, opt CaptchaOption) *image.NRGBA {
+	bounds := fg.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	if n := len(opt.Backgrounds); n > 0 {
+		bg := opt.Backgrounds[rand.IntN(n)]
+		xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), bg, bg.Bounds(), draw.Src, nil)
+	} else {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(opt.BgColor), image.Point{}, draw.Src)
+	}
+	draw.Draw(dst, dst.Bounds(), fg, bounds.Min, draw.Over)
+	return dst
+}
+
+// clearPalette 数字验证码的透明色是 {0xFF, 0xFF, 0xFF, 0x00}，不是合法的预乘 alpha 颜色，
+// 直接叠加到背景上会变成白色，这里替换成真正的透明色
+func clearPalette(p *image.Paletted) *image.Paletted {
+	palette := make(color.Palette, len(p.Palette))
+	for i, c := range p.Palette {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			c = color.Transparent
+		}
+		palette[i] = c
+	}
+	return &image.Paletted{Pix: p.Pix, Stride: p.Stride, Rect: p.Rect, Palette: palette}
+}
+
+// flatten 把透明部分填充为 bg，用于不支持透明的格式
+func flatten(img image.Image, bg color.Color) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
+
+// dataURI 生成 data URI，前端可以直接用在 img 或 audio 标签上
+func dataURI(contentType string, data []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
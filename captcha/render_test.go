@@ -0,0 +1,124 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestRenderFormats(t *testing.T) {
+	ctx := context.Background()
+	service := New(NewMemoryCaptchaStore())
+
+	tests := []struct {
+		format      ImageFormat
+		contentType string
+		decode      func([]byte) error
+	}{
+		{format: FormatPNG, contentType: "image/png"},
+		{format: FormatJPEG, contentType: "image/jpeg", decode: func(b []byte) error {
+			_, err := jpeg.Decode(bytes.NewReader(b))
+			return err
+		}},
+		{format: FormatGIF, contentType: "image/gif", decode: func(b []byte) error {
+			_, err := gif.Decode(bytes.NewReader(b))
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			resp, err := service.GenerateCaptcha(ctx, CaptchaOption{Type: CaptchaTypeString, Format: tt.format})
+			if err != nil {
+				t.Fatalf("GenerateCaptcha failed: %v", err)
+			}
+			if resp.ContentType != tt.contentType {
+				t.Fatalf("expected content type %s, got %s", tt.contentType, resp.ContentType)
+			}
+			prefix := "data:" + tt.contentType + ";base64,"
+			if resp.ImageBase64 != prefix+base64.StdEncoding.EncodeToString(resp.Data) {
+				t.Fatal("ImageBase64 does not match Data")
+			}
+			if tt.decode != nil {
+				if err = tt.decode(resp.Data); err != nil {
+					t.Fatalf("decode %s failed: %v", tt.format, err)
+				}
+			}
+		})
+	}
+
+	if _, err := service.GenerateCaptcha(ctx, CaptchaOption{Format: "bmp"}); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestRenderBackground(t *testing.T) {
+	ctx := context.Background()
+	red := image.NewUniform(color.RGBA{R: 255, A: 255})
+	bg := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			bg.Set(x, y, red)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opt  CaptchaOption
+	}{
+		{name: "digit image", opt: CaptchaOption{Type: CaptchaTypeDigit, Backgrounds: []image.Image{bg}}},
+		{name: "string image", opt: CaptchaOption{Type: CaptchaTypeString, Backgrounds: []image.Image{bg}, LineOptions: LineSine}},
+		{name: "digit color", opt: CaptchaOption{Type: CaptchaTypeDigit, BgColor: &color.RGBA{R: 255, A: 255}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := New(NewMemoryCaptchaStore()).GenerateCaptcha(ctx, tt.opt)
+			if err != nil {
+				t.Fatalf("GenerateCaptcha failed: %v", err)
+			}
+			// 背景图被缩放到验证码尺寸，大部分像素应该是背景色
+			bounds := resp.Image.Bounds()
+			if bounds.Dx() != 120 || bounds.Dy() != 40 {
+				t.Fatalf("unexpected size %v", bounds)
+			}
+			red := 0
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+					if r, g, b, a := resp.Image.At(x, y).RGBA(); r == 0xffff && g == 0 && b == 0 && a == 0xffff {
+						red++
+					}
+				}
+			}
+			if red < bounds.Dx()*bounds.Dy()/3 {
+				t.Fatalf("expected background to dominate, got %d red pixels", red)
+			}
+		})
+	}
+}
+
+func TestRenderCustomFonts(t *testing.T) {
+	ctx := context.Background()
+
+	service := New(NewMemoryCaptchaStore(), CaptchaOption{Type: CaptchaTypeString, Fonts: [][]byte{goregular.TTF}, NoiseCount: 3})
+	resp, err := service.GenerateCaptcha(ctx, CaptchaOption{})
+	if err != nil {
+		t.Fatalf("GenerateCaptcha failed: %v", err)
+	}
+	if !strings.HasPrefix(resp.ImageBase64, "data:image/png;base64,") {
+		t.Fatalf("unexpected image %q", resp.ImageBase64[:32])
+	}
+
+	service = New(NewMemoryCaptchaStore(), CaptchaOption{Type: CaptchaTypeString, Fonts: [][]byte{[]byte("not a font")}})
+	if _, err = service.GenerateCaptcha(ctx, CaptchaOption{}); err == nil {
+		t.Fatal("expected error for invalid font")
+	}
+}
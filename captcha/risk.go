@@ -0,0 +1,243 @@
+package captcha
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed risk_incr.lua
+var luaRiskIncr string
+
+var riskIncrScript = redis.NewScript(luaRiskIncr)
+
+// RiskStore 失败次数计数存储
+type RiskStore interface {
+	// Incr 失败次数加一并返回加一后的值，计数在第一次失败后 window 时间内有效
+	Incr(ctx context.Context, key string, window time.Duration) (int, error)
+	// Count 返回当前失败次数，没有记录时返回 0
+	Count(ctx context.Context, key string) (int, error)
+	// Reset 清除失败次数
+	Reset(ctx context.Context, key string) error
+}
+
+// Challenge 风控评估结果
+type Challenge struct {
+	Required   bool // 是否需要验证码
+	Complexity int  // 建议的复杂度级别，Required 为 false 时为 0
+	Failures   int  // 所有 key 中最大的失败次数
+}
+
+// Option 返回按评估结果设置了复杂度的验证码配置，可以直接传给 GenerateCaptcha
+func (c Challenge) Option() CaptchaOption {
+	return CaptchaOption{Complexity: c.Complexity}
+}
+
+// RiskPolicy 基于失败次数的自适应验证码策略
+//
+// 按 IP、账号、设备等维度统计失败次数（登录失败、验证码答错等），
+// 失败次数达到 Threshold 后才要求验证码，之后随失败次数增加逐级提高复杂度。
+// 多个维度同时评估时取失败次数最多的一个。
+type RiskPolicy struct {
+	store   RiskStore
+	options RiskOption
+}
+
+// NewRiskPolicy 创建自适应验证码策略
+func NewRiskPolicy(store RiskStore, options RiskOption) *RiskPolicy {
+	if options.Window == 0 {
+		options.Window = 15 * time.Minute
+	}
+	if options.Threshold == 0 {
+		options.Threshold = 3
+	}
+	if options.MediumAt == 0 {
+		options.MediumAt = options.Threshold + 3
+	}
+	if options.HighAt == 0 {
+		options.HighAt = options.MediumAt + 4
+	}
+	return &RiskPolicy{
+		store:   store,
+		options: options,
+	}
+}
+
+// Evaluate 评估是否需要验证码以及使用的复杂度，keys 为空字符串时忽略
+func (p *RiskPolicy) Evaluate(ctx context.Context, keys ...string) (Challenge, error) {
+	failures := 0
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		n, err := p.store.Count(ctx, key)
+		if err != nil {
+			return Challenge{}, err
+		}
+		failures = max(failures, n)
+	}
+	return p.challenge(failures), nil
+}
+
+// RecordFailure 为每个 key 记录一次失败，返回记录后的评估结果
+func (p *RiskPolicy) RecordFailure(ctx context.Context, keys ...string) (Challenge, error) {
+	failures := 0
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		n, err := p.store.Incr(ctx, key, p.options.Window)
+		if err != nil {
+			return Challenge{}, err
+		}
+		failures = max(failures, n)
+	}
+	return p.challenge(failures), nil
+}
+
+// RecordSuccess 清除 keys 的失败次数，通常在登录成功后只清除账号和设备维度，保留 IP 维度
+func (p *RiskPolicy) RecordSuccess(ctx context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		if key != "" {
+			errs = append(errs, p.store.Reset(ctx, key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *RiskPolicy) challenge(failures int) Challenge {
+	c := Challenge{Failures: failures}
+	switch {
+	case failures < p.options.Threshold:
+		return c
+	case failures >= p.options.HighAt:
+		c.Complexity = ComplexityHigh
+	case failures >= p.options.MediumAt:
+		c.Complexity = ComplexityMedium
+	default:
+		c.Complexity = ComplexityLow
+	}
+	c.Required = true
+	return c
+}
+
+// RedisRiskStore 基于 Redis 的失败次数计数存储
+type RedisRiskStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisRiskStore 创建基于 Redis 的失败次数计数存储，prefix 默认 "captcha:risk:"
+func NewRedisRiskStore(client redis.Cmdable, prefix string) *RedisRiskStore {
+	if prefix == "" {
+		prefix = "captcha:risk:"
+	}
+	return &RedisRiskStore{client: client, prefix: prefix}
+}
+
+// Incr 失败次数加一，第一次失败时设置过期时间
+func (r *RedisRiskStore) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	return riskIncrScript.Run(ctx, r.client, []string{r.prefix + key}, window.Milliseconds()).Int()
+}
+
+// Count 返回当前失败次数
+func (r *RedisRiskStore) Count(ctx context.Context, key string) (int, error) {
+	n, err := r.client.Get(ctx, r.prefix+key).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Reset 清除失败次数
+func (r *RedisRiskStore) Reset(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key).Err()
+}
+
+type riskCounter struct {
+	count    int
+	expireAt time.Time
+}
+
+// MemoryRiskStore 基于内存的失败次数计数存储，适合单实例部署和测试
+type MemoryRiskStore struct {
+	mu        sync.Mutex
+	data      map[string]riskCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryRiskStore 创建基于内存的失败次数计数存储
+func NewMemoryRiskStore() *MemoryRiskStore {
+	return &MemoryRiskStore{
+		data: make(map[string]riskCounter),
+		now:  time.Now,
+	}
+}
+
+// Incr 失败次数加一，第一次失败时设置过期时间
+func (m *MemoryRiskStore) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+	c, ok := m.data[key]
+	if !ok || !now.Before(c.expireAt) {
+		c = riskCounter{expireAt: now.Add(window)}
+	}
+	c.count++
+	m.data[key] = c
+	return c.count, nil
+}
+
+// Count 返回当前失败次数
+func (m *MemoryRiskStore) Count(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.data[key]
+	if !ok || !m.now().Before(c.expireAt) {
+		return 0, nil
+	}
+	return c.count, nil
+}
+
+// Reset 清除失败次数
+func (m *MemoryRiskStore) Reset(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.data, key)
+	return nil
+}
+
+// sweep 每分钟最多清理一次过期的计数，避免长期运行时 map 无限增长
+func (m *MemoryRiskStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, c := range m.data {
+		if !now.Before(c.expireAt) {
+			delete(m.data, key)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+-- KEYS[1]: failure counter key
+-- ARGV[1]: window in milliseconds, set when the counter is created
+-- returns the counter after increment
+local n = redis.call('INCR', KEYS[1])
+if n == 1 or redis.call('PTTL', KEYS[1]) < 0 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
@@ -0,0 +1,81 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRiskPolicyEscalation(t *testing.T) {
+	ctx := context.Background()
+	policy := NewRiskPolicy(NewMemoryRiskStore(), RiskOption{Threshold: 2, MediumAt: 3, HighAt: 5})
+
+	want := []Challenge{
+		{Failures: 1},
+		{Required: true, Complexity: ComplexityLow, Failures: 2},
+		{Required: true, Complexity: ComplexityMedium, Failures: 3},
+		{Required: true, Complexity: ComplexityMedium, Failures: 4},
+		{Required: true, Complexity: ComplexityHigh, Failures: 5},
+	}
+	for i, w := range want {
+		got, err := policy.RecordFailure(ctx, "ip:10.0.0.1")
+		if err != nil {
+			t.Fatalf("RecordFailure failed: %v", err)
+		}
+		if got != w {
+			t.Fatalf("failure %d: got %+v, want %+v", i+1, got, w)
+		}
+	}
+
+	// 多个维度取失败次数最多的一个
+	got, err := policy.Evaluate(ctx, "account:bob", "ip:10.0.0.1", "")
+	if err != nil || got.Complexity != ComplexityHigh {
+		t.Fatalf("Evaluate = %+v, %v", got, err)
+	}
+
+	if err = policy.RecordSuccess(ctx, "ip:10.0.0.1"); err != nil {
+		t.Fatalf("RecordSuccess failed: %v", err)
+	}
+	if got, _ = policy.Evaluate(ctx, "ip:10.0.0.1"); got.Required {
+		t.Fatalf("expected no challenge after success, got %+v", got)
+	}
+}
+
+func TestMemoryRiskStoreWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRiskStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, _ = store.Incr(ctx, "k", time.Minute)
+	}
+	if n, _ := store.Count(ctx, "k"); n != 3 {
+		t.Fatalf("expected 3 failures, got %d", n)
+	}
+
+	now = now.Add(time.Minute)
+	if n, _ := store.Count(ctx, "k"); n != 0 {
+		t.Fatalf("expected failures to expire, got %d", n)
+	}
+	if n, _ := store.Incr(ctx, "k", time.Minute); n != 1 {
+		t.Fatalf("expected a new window, got %d", n)
+	}
+}
+
+func TestHandlerRiskPolicy(t *testing.T) {
+	ctx := context.Background()
+	policy := NewRiskPolicy(NewMemoryRiskStore(), RiskOption{})
+	h := NewHandler(New(NewMemoryCaptchaStore()), WithRiskPolicy(policy))
+
+	for i := 0; i < 3; i++ {
+		_, created := doRequest[CaptchaResponse](t, h, http.MethodPost, "/captcha/generate", "")
+		doRequest[verifyResult](t, h, http.MethodPost, "/captcha/verify", `{"id":"`+created.Data.ID+`","answer":"wrong"}`)
+	}
+
+	c, err := policy.Evaluate(ctx, "10.0.0.1")
+	if err != nil || !c.Required || c.Failures != 3 {
+		t.Fatalf("Evaluate = %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,202 @@
+package captcha
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+// sliderKeyPrefix 滑块验证码在 CaptchaStore 中的 key 前缀
+const sliderKeyPrefix = "slider:"
+
+// DefaultSliderCaptchaService 默认滑块拼图验证码服务
+//
+// 生成时从背景图上挖出一块拼图，拼图的横向偏移量保存在 CaptchaStore 中；
+// 校验时比较提交的偏移量（允许 Tolerance 误差），并对拖动轨迹做基本检查，
+// 拦截直接提交答案或没有真实拖动过程的脚本。每个滑块验证码只能校验一次。
+type DefaultSliderCaptchaService struct {
+	store   CaptchaStore
+	options SliderOption
+}
+
+// NewSliderCaptchaService 创建滑块拼图验证码服务
+func NewSliderCaptchaService(store CaptchaStore, options SliderOption) *DefaultSliderCaptchaService {
+	if options.ExpireTime == 0 {
+		options.ExpireTime = 2 * time.Minute // 默认2分钟过期
+	}
+	if options.Width == 0 {
+		options.Width = 300 // 默认宽度
+	}
+	if options.Height == 0 {
+		options.Height = 150 // 默认高度
+	}
+	if options.PieceSize == 0 {
+		options.PieceSize = 50 // 默认拼图块边长
+	}
+	if options.Tolerance == 0 {
+		options.Tolerance = 5 // 默认允许5像素误差
+	}
+	if options.MinDuration == 0 {
+		options.MinDuration = 300 * time.Millisecond
+	}
+	if options.MaxDuration == 0 {
+		options.MaxDuration = 30 * time.Second
+	}
+	if options.MinTrackPoints == 0 {
+		options.MinTrackPoints = 5
+	}
+
+	return &DefaultSliderCaptchaService{
+		store:   store,
+		options: options,
+	}
+}
+
+// GenerateSliderCaptcha 生成滑块拼图验证码
+func (s *DefaultSliderCaptchaService) GenerateSliderCaptcha(ctx context.Context) (*SliderCaptchaResponse, error) {
+	bg := backgroundImage(s.options.Backgrounds, s.options.Width, s.options.Height)
+	width, height, size := bg.Bounds().Dx(), bg.Bounds().Dy(), s.options.PieceSize
+	// 拼图的初始位置在最左侧，目标位置至少离开一个拼图宽度，并和左右边缘留出 10 像素
+	if size <= 0 || width < 2*size+20 || height < size+10 {
+		return nil, ErrInvalidOption
+	}
+
+	x := size + 10 + rand.IntN(width-2*size-20+1)
+	y := 5 + rand.IntN(height-size-10+1)
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	black := color.NRGBA{A: 255}
+	mask := newPieceMask(size)
+	piece := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for j := 0; j < size; j++ {
+		for i := 0; i < size; i++ {
+			if !mask.inside(i, j) {
+				continue
+			}
+			c := bg.NRGBAAt(x+i, y+j)
+			if mask.edge(i, j) {
+				// 拼图块和缺口都描一圈浅色边
+				piece.SetNRGBA(i, j, blend(c, white, 0.7))
+				bg.SetNRGBA(x+i, y+j, blend(c, white, 0.5))
+			} else {
+				// 背景上对应位置压暗，形成缺口
+				piece.SetNRGBA(i, j, c)
+				bg.SetNRGBA(x+i, y+j, blend(c, black, 0.5))
+			}
+		}
+	}
+
+	bgBase64, err := encodePNG(bg)
+	if err != nil {
+		return nil, err
+	}
+	pieceBase64, err := encodePNG(piece)
+	if err != nil {
+		return nil, err
+	}
+
+	id := base64Captcha.RandomId()
+	if err = s.store.Set(ctx, sliderKeyPrefix+id, strconv.Itoa(x), s.options.ExpireTime); err != nil {
+		return nil, err
+	}
+
+	return &SliderCaptchaResponse{
+		ID:               id,
+		Background:       bg,
+		Piece:            piece,
+		BackgroundBase64: bgBase64,
+		PieceBase64:      pieceBase64,
+		Width:            width,
+		Height:           height,
+		PieceSize:        size,
+		PieceY:           y,
+		ExpireAt:         time.Now().Add(s.options.ExpireTime),
+		X:                x,
+	}, nil
+}
+
+// VerifySlider 校验滑块位置和拖动轨迹
+//
+// 无论校验是否通过，验证码都会被删除，失败后需要重新生成。
+func (s *DefaultSliderCaptchaService) VerifySlider(ctx context.Context, id string, answer SliderAnswer) (bool, error) {
+	stored, err := s.store.Take(ctx, sliderKeyPrefix+id)
+	if err != nil {
+		return false, err
+	}
+	x, err := strconv.Atoi(stored)
+	if err != nil {
+		return false, ErrCaptchaNotFound
+	}
+
+	if abs(answer.X-x) > s.options.Tolerance {
+		return false, nil
+	}
+	return s.checkTrack(answer), nil
+}
+
+// checkTrack 检查拖动轨迹：点数足够、时间递增且耗时合理、横向基本单调、终点和提交位置一致
+func (s *DefaultSliderCaptchaService) checkTrack(answer SliderAnswer) bool {
+	track := answer.Track
+	if len(track) < s.options.MinTrackPoints {
+		return false
+	}
+
+	tolerance := s.options.Tolerance
+	maxX := track[0].X
+	for i := 1; i < len(track); i++ {
+		if track[i].T < track[i-1].T {
+			return false
+		}
+		// 允许松手前小幅回拉校准，但不允许大幅往回拖
+		if track[i].X < maxX-tolerance {
+			return false
+		}
+		maxX = max(maxX, track[i].X)
+	}
+
+	duration := time.Duration(track[len(track)-1].T-track[0].T) * time.Millisecond
+	if duration < s.options.MinDuration || duration > s.options.MaxDuration {
+		return false
+	}
+
+	// 轨迹终点必须和提交的位置一致
+	return abs(track[len(track)-1].X-answer.X) <= tolerance
+}
+
+// pieceMask 拼图形状：正方形主体，上边和右边各有一个半圆凸起
+type pieceMask struct {
+	size   int
+	radius int
+}
+
+func newPieceMask(size int) pieceMask {
+	return pieceMask{size: size, radius: size / 6}
+}
+
+func (m pieceMask) inside(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.size || y >= m.size {
+		return false
+	}
+	r := m.radius
+	body := m.size - r
+	// 主体区域：左下角的 body×body 正方形
+	if x < body && y >= r {
+		return true
+	}
+	// 上方凸起，圆心在主体上边中点
+	if inCircle(x, y, body/2, r, r) {
+		return true
+	}
+	// 右侧凸起，圆心在主体右边中点
+	return inCircle(x, y, body, r+body/2, r)
+}
+
+// edge 判断像素是否在拼图边缘，用于描边
+func (m pieceMask) edge(x, y int) bool {
+	return !m.inside(x-1, y) || !m.inside(x+1, y) || !m.inside(x, y-1) || !m.inside(x, y+1)
+}
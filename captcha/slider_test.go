@@ -0,0 +1,154 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"strconv"
+	"testing"
+)
+
+// humanTrack 模拟一段先快后慢、最后小幅回拉的拖动轨迹
+func humanTrack(target int) []TrackPoint {
+	track := []TrackPoint{{X: 0, Y: 0, T: 0}}
+	for i, p := range []float64{0.2, 0.5, 0.8, 0.95, 1.02} {
+		track = append(track, TrackPoint{X: int(float64(target) * p), Y: i % 2, T: int64(120 * (i + 1))})
+	}
+	return append(track, TrackPoint{X: target, Y: 1, T: 800})
+}
+
+func TestSliderCaptcha(t *testing.T) {
+	ctx := context.Background()
+	service := NewSliderCaptchaService(NewMemoryCaptchaStore(), SliderOption{})
+
+	resp, err := service.GenerateSliderCaptcha(ctx)
+	if err != nil {
+		t.Fatalf("GenerateSliderCaptcha failed: %v", err)
+	}
+	if resp.Background.Bounds().Dx() != 300 || resp.Piece.Bounds().Dx() != 50 {
+		t.Fatalf("unexpected image sizes: %v, %v", resp.Background.Bounds(), resp.Piece.Bounds())
+	}
+	if resp.BackgroundBase64 == "" || resp.PieceBase64 == "" {
+		t.Fatal("expected base64 images")
+	}
+
+	ok, err := service.VerifySlider(ctx, resp.ID, SliderAnswer{X: resp.X + 3, Track: humanTrack(resp.X + 3)})
+	if err != nil || !ok {
+		t.Fatalf("VerifySlider = %v, %v", ok, err)
+	}
+
+	// 每个滑块验证码只能校验一次
+	if _, err = service.VerifySlider(ctx, resp.ID, SliderAnswer{X: resp.X, Track: humanTrack(resp.X)}); !errors.Is(err, ErrCaptchaNotFound) {
+		t.Fatalf("expected ErrCaptchaNotFound, got %v", err)
+	}
+}
+
+func TestSliderCaptchaRejects(t *testing.T) {
+	ctx := context.Background()
+	service := NewSliderCaptchaService(NewMemoryCaptchaStore(), SliderOption{})
+
+	tests := []struct {
+		name   string
+		answer func(x int) SliderAnswer
+	}{
+		{name: "wrong offset", answer: func(x int) SliderAnswer {
+			return SliderAnswer{X: x + 20, Track: humanTrack(x + 20)}
+		}},
+		{name: "no track", answer: func(x int) SliderAnswer {
+			return SliderAnswer{X: x}
+		}},
+		{name: "too fast", answer: func(x int) SliderAnswer {
+			track := humanTrack(x)
+			for i := range track {
+				track[i].T /= 10
+			}
+			return SliderAnswer{X: x, Track: track}
+		}},
+		{name: "moves backwards", answer: func(x int) SliderAnswer {
+			track := humanTrack(x)
+			track[3].X = 0
+			return SliderAnswer{X: x, Track: track}
+		}},
+		{name: "track ends elsewhere", answer: func(x int) SliderAnswer {
+			return SliderAnswer{X: x, Track: humanTrack(x / 2)}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.GenerateSliderCaptcha(ctx)
+			if err != nil {
+				t.Fatalf("GenerateSliderCaptcha failed: %v", err)
+			}
+			ok, err := service.VerifySlider(ctx, resp.ID, tt.answer(resp.X))
+			if err != nil || ok {
+				t.Fatalf("VerifySlider = %v, %v; want rejection", ok, err)
+			}
+		})
+	}
+}
+
+func TestSliderCaptchaSize(t *testing.T) {
+	ctx := context.Background()
+
+	// 宽度至少为 2*PieceSize+20
+	service := NewSliderCaptchaService(NewMemoryCaptchaStore(), SliderOption{Width: 36, Height: 40, PieceSize: 12})
+	if _, err := service.GenerateSliderCaptcha(ctx); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption, got %v", err)
+	}
+
+	service = NewSliderCaptchaService(NewMemoryCaptchaStore(), SliderOption{Width: 44, Height: 40, PieceSize: 12})
+	resp, err := service.GenerateSliderCaptcha(ctx)
+	if err != nil {
+		t.Fatalf("GenerateSliderCaptcha failed: %v", err)
+	}
+	if resp.X != 22 {
+		t.Fatalf("expected the only possible offset 22, got %d", resp.X)
+	}
+}
+
+func TestSliderCaptchaResponseJSON(t *testing.T) {
+	store := NewMemoryCaptchaStore()
+	resp, err := NewSliderCaptchaService(store, SliderOption{}).GenerateSliderCaptcha(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateSliderCaptcha failed: %v", err)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	if _, ok := fields["x"]; ok {
+		t.Fatal("the answer must not be serialized")
+	}
+
+	// 答案也不能用于图片验证码
+	if ok, _ := New(store).VerifyCaptcha(context.Background(), resp.ID, strconv.Itoa(resp.X)); ok {
+		t.Fatal("slider captcha passed image verification")
+	}
+}
+
+func TestSliderCustomBackground(t *testing.T) {
+	ctx := context.Background()
+
+	service := NewSliderCaptchaService(NewMemoryCaptchaStore(), SliderOption{
+		Backgrounds: []image.Image{randomBackground(400, 200)},
+	})
+	resp, err := service.GenerateSliderCaptcha(ctx)
+	if err != nil {
+		t.Fatalf("GenerateSliderCaptcha failed: %v", err)
+	}
+	if resp.Width != 400 || resp.Height != 200 {
+		t.Fatalf("expected 400x200 background, got %dx%d", resp.Width, resp.Height)
+	}
+
+	service = NewSliderCaptchaService(NewMemoryCaptchaStore(), SliderOption{
+		Backgrounds: []image.Image{randomBackground(60, 40)},
+	})
+	if _, err = service.GenerateSliderCaptcha(ctx); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package captcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ticketKeyPrefix 票据在 CaptchaStore 中的 key 前缀
+const ticketKeyPrefix = "ticket:"
+
+// Ticketer 验证通过票据的签发和兑换
+//
+// 验证码校验和受保护的操作（如登录）分在两个请求甚至两个服务时，校验通过后签发一张票据，
+// 后续请求凭票据兑换。票据用 HMAC-SHA256 签名，绑定验证码 ID 和可选的客户端指纹
+// （如 IP、设备 ID），有效期短且只能兑换一次。一次性通过 CaptchaStore 保证，
+// 所以签发方和兑换方需要共享同一个存储（例如同一个 Redis）和同一个密钥。
+type Ticketer struct {
+	secret []byte
+	store  CaptchaStore
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTicketer 创建票据签发器，secret 至少 16 字节，ttl 为 0 时默认 2 分钟
+func NewTicketer(secret []byte, store CaptchaStore, ttl time.Duration) (*Ticketer, error) {
+	if len(secret) < 16 {
+		return nil, ErrInvalidOption
+	}
+	if ttl == 0 {
+		ttl = 2 * time.Minute
+	}
+	return &Ticketer{
+		secret: append([]byte(nil), secret...),
+		store:  store,
+		ttl:    ttl,
+		now:    time.Now,
+	}, nil
+}
+
+// Issue 为校验通过的验证码签发票据，fingerprint 为空表示不绑定客户端
+func (t *Ticketer) Issue(ctx context.Context, captchaID, fingerprint string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b[:])
+	expireAt := t.now().Add(t.ttl)
+
+	if err := t.store.Set(ctx, ticketKeyPrefix+nonce, captchaID, t.ttl); err != nil {
+		return "", err
+	}
+
+	payload := nonce + "|" + strconv.FormatInt(expireAt.UnixMilli(), 10) + "|" + captchaID
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(t.sign(payload, fingerprint)), nil
+}
+
+// Redeem 兑换票据，成功时返回签发票据的验证码 ID
+//
+// 签名不对或指纹不匹配返回 ErrInvalidTicket，过期返回 ErrTicketExpired，
+// 已经兑换过返回 ErrTicketUsed。
+func (t *Ticketer) Redeem(ctx context.Context, ticket, fingerprint string) (string, error) {
+	encoded, sig, ok := strings.Cut(ticket, ".")
+	if !ok {
+		return "", ErrInvalidTicket
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidTicket
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidTicket
+	}
+	payload := string(raw)
+	if !hmac.Equal(mac, t.sign(payload, fingerprint)) {
+		return "", ErrInvalidTicket
+	}
+
+	parts := strings.SplitN(payload, "|", 3)
+	if len(parts) != 3 {
+		return "", ErrInvalidTicket
+	}
+	nonce, captchaID := parts[0], parts[2]
+	expireAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrInvalidTicket
+	}
+	if t.now().UnixMilli() > expireAt {
+		return "", ErrTicketExpired
+	}
+
+	stored, err := t.store.Take(ctx, ticketKeyPrefix+nonce)
+	if errors.Is(err, ErrCaptchaNotFound) {
+		return "", ErrTicketUsed
+	}
+	if err != nil {
+		return "", err
+	}
+	if stored != captchaID {
+		return "", ErrInvalidTicket
+	}
+	return captchaID, nil
+}
+
+// sign 计算票据签名，指纹参与签名但不写进票据
+func (t *Ticketer) sign(payload, fingerprint string) []byte {
+	h := hmac.New(sha256.New, t.secret)
+	h.Write([]byte(payload))
+	h.Write([]byte{0})
+	h.Write([]byte(fingerprint))
+	return h.Sum(nil)
+}
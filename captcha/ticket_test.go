@@ -0,0 +1,73 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testTicketSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestTicketIssueRedeem(t *testing.T) {
+	ctx := context.Background()
+	tickets, err := NewTicketer(testTicketSecret, NewMemoryCaptchaStore(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewTicketer failed: %v", err)
+	}
+
+	ticket, err := tickets.Issue(ctx, "captcha-id", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if _, err = tickets.Redeem(ctx, ticket, "10.0.0.2"); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for another fingerprint, got %v", err)
+	}
+
+	id, err := tickets.Redeem(ctx, ticket, "10.0.0.1")
+	if err != nil || id != "captcha-id" {
+		t.Fatalf("Redeem = %q, %v", id, err)
+	}
+
+	if _, err = tickets.Redeem(ctx, ticket, "10.0.0.1"); !errors.Is(err, ErrTicketUsed) {
+		t.Fatalf("expected ErrTicketUsed, got %v", err)
+	}
+}
+
+func TestTicketRejects(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCaptchaStore()
+	tickets, _ := NewTicketer(testTicketSecret, store, time.Minute)
+
+	ticket, err := tickets.Issue(ctx, "captcha-id", "")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	// 篡改签名
+	tampered := []byte(ticket)
+	tampered[len(tampered)-2] ^= 1
+	if _, err = tickets.Redeem(ctx, string(tampered), ""); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for tampered ticket, got %v", err)
+	}
+	if _, err = tickets.Redeem(ctx, "garbage", ""); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for garbage, got %v", err)
+	}
+
+	// 其他密钥签发的票据
+	other, _ := NewTicketer([]byte("fedcba9876543210fedcba9876543210"), store, time.Minute)
+	if _, err = other.Redeem(ctx, ticket, ""); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for another secret, got %v", err)
+	}
+
+	// 过期
+	tickets.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err = tickets.Redeem(ctx, ticket, ""); !errors.Is(err, ErrTicketExpired) {
+		t.Fatalf("expected ErrTicketExpired, got %v", err)
+	}
+
+	if _, err = NewTicketer([]byte("short"), store, 0); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption for short secret, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package captcha
 import (
 	"context"
 	"image"
+	"image/color"
 	"time"
 )
 
@@ -11,6 +12,11 @@ type CaptchaStore interface {
 	Set(ctx context.Context, id string, value string, expire time.Duration) error
 	Get(ctx context.Context, id string) (string, error)
 	Delete(ctx context.Context, id string) error
+	// Take 原子地读取并删除验证码，验证码不存在时返回 ErrCaptchaNotFound
+	Take(ctx context.Context, id string) (string, error)
+	// Verify 原子地校验答案：答对立即删除；答错累计次数，累计达到 maxAttempts 次后删除，
+	// maxAttempts <= 0 表示不限制次数。验证码不存在时返回 ErrCaptchaNotFound
+	Verify(ctx context.Context, id, answer string, maxAttempts int) (bool, error)
 }
 
 // ImageCaptchaService 图片验证码服务接口
@@ -20,21 +26,178 @@ type ImageCaptchaService interface {
 	DeleteCaptcha(ctx context.Context, id string) error
 }
 
+// SliderCaptchaService 滑块拼图验证码服务接口
+type SliderCaptchaService interface {
+	GenerateSliderCaptcha(ctx context.Context) (*SliderCaptchaResponse, error)
+	VerifySlider(ctx context.Context, id string, answer SliderAnswer) (bool, error)
+}
+
+// ClickCaptchaService 文字点选验证码服务接口
+type ClickCaptchaService interface {
+	GenerateClickCaptcha(ctx context.Context) (*ClickCaptchaResponse, error)
+	VerifyClick(ctx context.Context, id string, points []ClickPoint) (bool, error)
+}
+
+// PowCaptchaService 工作量证明验证码服务接口
+type PowCaptchaService interface {
+	GeneratePow(ctx context.Context, difficulty int) (*PowChallenge, error)
+	VerifyPow(ctx context.Context, id, solution string) (bool, error)
+}
+
+// CaptchaType 验证码类型
+type CaptchaType string
+
+const (
+	// CaptchaTypeDigit 纯数字验证码（默认）
+	CaptchaTypeDigit CaptchaType = "digit"
+	// CaptchaTypeString 字母数字混合验证码，已去掉 0/O、1/I/L 等容易混淆的字符
+	CaptchaTypeString CaptchaType = "string"
+	// CaptchaTypeMath 算术验证码，图片显示算式，答案为计算结果
+	CaptchaTypeMath CaptchaType = "math"
+	// CaptchaTypeChinese 中文汉字验证码
+	CaptchaTypeChinese CaptchaType = "chinese"
+	// CaptchaTypeAudio 语音验证码（WAV），用于无障碍场景，答案为数字
+	CaptchaTypeAudio CaptchaType = "audio"
+	// CaptchaTypeClick 文字点选验证码，由 DefaultClickCaptchaService 生成
+	CaptchaTypeClick CaptchaType = "click"
+	// CaptchaTypePow 工作量证明（无感验证），由 DefaultPowCaptchaService 生成
+	CaptchaTypePow CaptchaType = "pow"
+)
+
+// 复杂度级别，控制干扰点、干扰线和扭曲程度；0 等同于 ComplexityMedium
+const (
+	ComplexityLow    = 1
+	ComplexityMedium = 2
+	ComplexityHigh   = 3
+)
+
 // CaptchaOption 验证码配置选项
 type CaptchaOption struct {
 	ExpireTime time.Duration // 过期时间
-	Length     int          // 验证码长度
-	Width      int          // 图片宽度
-	Height     int          // 图片高度
-	Complexity int          // 复杂度级别
+	Length     int           // 验证码长度（算术验证码忽略此项）
+	Width      int           // 图片宽度
+	Height     int           // 图片高度
+	Complexity int           // 复杂度级别，见 ComplexityLow/ComplexityMedium/ComplexityHigh
+	Type       CaptchaType   // 验证码类型，默认 CaptchaTypeDigit
+	Language   string        // 语音验证码语言：en、zh、ja、ru，默认 en
+	// MaxAttempts 同一个验证码最多允许答错的次数，达到后验证码失效，默认 3；小于 0 表示不限制
+	MaxAttempts int
+
+	Fonts       [][]byte      // 自定义 TTF/TTC 字体文件内容，用于字母数字、算术和中文验证码，默认使用内置字体
+	Backgrounds []image.Image // 背景图，随机选一张缩放到图片尺寸；语音验证码忽略此项
+	BgColor     *color.RGBA   // 背景色，默认随机浅色（数字验证码默认透明），设置了 Backgrounds 时忽略
+	NoiseCount  int           // 干扰数量：数字验证码为干扰点数，其他为干扰字符数；0 表示按 Complexity 决定
+	LineOptions int           // 干扰线，LineHollow、LineSlime、LineSine 的组合；0 表示按 Complexity 决定
+	Format      ImageFormat   // 图片输出格式，默认 FormatPNG
 }
 
 // CaptchaResponse 验证码响应结构
 type CaptchaResponse struct {
-	ID       string      `json:"id"`
-	Image    image.Image `json:"-"`      // 图片数据
-	ImageURL string      `json:"image_url,omitempty"` // 图片URL（可选）
-	ImageBase64 string   `json:"image_base64"`        // base64格式的图片数据
-	Value    string      `json:"value,omitempty"`     // 验证码值（仅用于测试，生产环境不应返回）
-	ExpireAt time.Time   `json:"expire_at"`
-}
\ No newline at end of file
+	ID          string      `json:"id"`
+	Type        CaptchaType `json:"type,omitempty"`         // 验证码类型
+	Image       image.Image `json:"-"`                      // 图片数据（语音验证码为 nil）
+	ImageURL    string      `json:"image_url,omitempty"`    // 图片URL（可选）
+	ImageBase64 string      `json:"image_base64"`           // base64格式的图片数据
+	AudioBase64 string      `json:"audio_base64,omitempty"` // base64格式的语音数据（仅语音验证码）
+	Data        []byte      `json:"-"`                      // 编码后的图片或语音原始数据，可以直接写入 HTTP 响应
+	ContentType string      `json:"content_type,omitempty"` // Data 的 MIME 类型，如 image/png、audio/wav
+	Value       string      `json:"value,omitempty"`        // 验证码值（仅用于测试，生产环境不应返回）
+	ExpireAt    time.Time   `json:"expire_at"`
+}
+
+// SliderOption 滑块拼图验证码配置选项
+type SliderOption struct {
+	ExpireTime     time.Duration // 过期时间，默认2分钟
+	Width          int           // 背景图宽度，默认300
+	Height         int           // 背景图高度，默认150
+	PieceSize      int           // 拼图块边长（含凸起），默认50
+	Tolerance      int           // 允许的横向误差（像素），默认5
+	MinDuration    time.Duration // 最短拖动耗时，默认300毫秒
+	MaxDuration    time.Duration // 最长拖动耗时，默认30秒
+	MinTrackPoints int           // 轨迹最少采样点数，默认5
+	Backgrounds    []image.Image // 自定义背景图，随机选一张并按原尺寸使用；为空时随机生成
+}
+
+// SliderCaptchaResponse 滑块拼图验证码响应结构
+type SliderCaptchaResponse struct {
+	ID               string      `json:"id"`
+	Background       image.Image `json:"-"`                 // 带缺口的背景图
+	Piece            image.Image `json:"-"`                 // 拼图块
+	BackgroundBase64 string      `json:"background_base64"` // base64格式的背景图
+	PieceBase64      string      `json:"piece_base64"`      // base64格式的拼图块
+	Width            int         `json:"width"`             // 背景图宽度
+	Height           int         `json:"height"`            // 背景图高度
+	PieceSize        int         `json:"piece_size"`        // 拼图块边长
+	PieceY           int         `json:"piece_y"`           // 拼图块在背景图中的纵坐标，前端据此摆放拼图块
+	X                int         `json:"-"`                 // 缺口横坐标，即答案，不会序列化到 JSON，仅用于测试
+	ExpireAt         time.Time   `json:"expire_at"`
+}
+
+// TrackPoint 拖动轨迹采样点
+type TrackPoint struct {
+	X int   `json:"x"` // 拖动距离，即拼图块相对起点的横向偏移
+	Y int   `json:"y"` // 纵向偏移
+	T int64 `json:"t"` // 相对拖动开始的毫秒数
+}
+
+// SliderAnswer 滑块验证码的提交内容
+type SliderAnswer struct {
+	X     int          `json:"x"`     // 松手时拼图块的横向偏移
+	Track []TrackPoint `json:"track"` // 拖动轨迹
+}
+
+// ClickOption 文字点选验证码配置选项
+type ClickOption struct {
+	ExpireTime  time.Duration // 过期时间，默认2分钟
+	Width       int           // 图片宽度，默认300
+	Height      int           // 图片高度，默认200
+	Count       int           // 图上的字符数量，默认5
+	Targets     int           // 需要依次点击的字符数量，默认3，不能超过 Count
+	FontSize    int           // 字号（像素），默认32
+	MaxRotation int           // 字符最大旋转角度（度），默认45
+	Tolerance   int           // 点击位置允许超出字符外接矩形的距离（像素），默认4
+	Source      string        // 候选字符，默认常用汉字
+	Backgrounds []image.Image // 自定义背景图，随机选一张并按原尺寸使用；为空时随机生成
+}
+
+// ClickPoint 点击坐标，相对图片左上角
+type ClickPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// ClickCaptchaResponse 文字点选验证码响应结构
+type ClickCaptchaResponse struct {
+	CaptchaResponse
+	Prompt  string       `json:"prompt"`  // 提示文字，例如 "请依次点击：天 地 人"
+	Targets []string     `json:"targets"` // 需要依次点击的字符
+	Width   int          `json:"width"`   // 图片宽度
+	Height  int          `json:"height"`  // 图片高度
+	Points  []ClickPoint `json:"-"`       // 目标字符的中心点，即答案，不会序列化到 JSON，仅用于测试
+}
+
+// RiskOption 自适应验证码策略配置选项
+type RiskOption struct {
+	Window    time.Duration // 失败次数统计窗口，从第一次失败开始计算，默认15分钟
+	Threshold int           // 失败多少次后开始要求验证码，默认3，此时使用 ComplexityLow
+	MediumAt  int           // 失败多少次后使用 ComplexityMedium，默认 Threshold+3
+	HighAt    int           // 失败多少次后使用 ComplexityHigh，默认 MediumAt+4
+}
+
+// PowOption 工作量证明验证码配置选项
+type PowOption struct {
+	ExpireTime    time.Duration // 过期时间，默认2分钟
+	Difficulty    int           // 默认难度，即哈希结果要求的前导零比特数，默认18
+	MaxDifficulty int           // 单次请求允许的最大难度，默认32
+}
+
+// PowChallenge 工作量证明题目
+//
+// 客户端需要找到一个字符串 solution，使 SHA-256(Nonce + solution) 至少有 Difficulty 个前导零比特。
+type PowChallenge struct {
+	ID         string    `json:"id"`
+	Algorithm  string    `json:"algorithm"` // 固定为 "SHA-256"
+	Nonce      string    `json:"nonce"`
+	Difficulty int       `json:"difficulty"`
+	ExpireAt   time.Time `json:"expire_at"`
+}
@@ -0,0 +1,28 @@
+-- KEYS[1]: captcha key
+-- KEYS[2]: wrong-answer counter key, in the same slot as KEYS[1]
+-- ARGV[1]: normalized answer
+-- ARGV[2]: max wrong answers, <= 0 means unlimited
+-- returns 1 on match, 0 on mismatch, -1 when the captcha does not exist
+local stored = redis.call('GET', KEYS[1])
+if not stored then
+    redis.call('DEL', KEYS[2])
+    return -1
+end
+
+if stored == ARGV[1] then
+    redis.call('DEL', KEYS[1], KEYS[2])
+    return 1
+end
+
+local max = tonumber(ARGV[2])
+local attempts = redis.call('INCR', KEYS[2])
+if max > 0 and attempts >= max then
+    redis.call('DEL', KEYS[1], KEYS[2])
+    return 0
+end
+
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+    redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return 0
@@ -145,3 +145,34 @@ func TestWithRetryZeroOverridesClientDefault(t *testing.T) {
 		t.Fatalf("calls = %d, want 1", calls)
 	}
 }
+
+func TestRequestIDMiddlewarePropagatesContextID(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Request-ID"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var seen string
+	client := New()
+	client.Use(NewRequestIDMiddleware(func() string { return "generated" }), func(next Handler) Handler {
+		return func(ctx *Context) error {
+			seen, _ = RequestIDFromContext(ctx.Request.Context())
+			return next(ctx)
+		}
+	})
+
+	if _, err := client.Get(WithRequestID(context.Background(), "inbound"), server.URL); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if _, err := client.Get(context.Background(), server.URL); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "inbound" || got[1] != "generated" {
+		t.Fatalf("X-Request-ID headers = %v, want [inbound generated]", got)
+	}
+	if seen != "generated" {
+		t.Fatalf("request context ID = %q, want %q", seen, "generated")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"github.com/linorwang/goaid/internal/requestid"
 )
 
 // LoggerMiddleware logs request lifecycle events.
@@ -222,22 +224,39 @@ func NewUserAgentMiddleware(userAgent string) Middleware {
 	}
 }
 
+// WithRequestID returns a copy of ctx carrying requestID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return requestid.With(ctx, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID or
+// RequestIDMiddleware.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	return requestid.From(ctx)
+}
+
 // RequestIDMiddleware adds an X-Request-ID header.
 type RequestIDMiddleware struct {
 	generator func() string
 }
 
-// NewRequestIDMiddleware creates request ID middleware.
+// NewRequestIDMiddleware creates request ID middleware. A request ID already
+// present in the request context is propagated; otherwise a new one is
+// generated and stored in the request context, so loggers that read
+// RequestIDFromContext can correlate log lines with the outgoing request.
 func NewRequestIDMiddleware(generator func() string) Middleware {
 	rm := &RequestIDMiddleware{generator: generator}
 
 	return func(next Handler) Handler {
 		return func(ctx *Context) error {
-			requestID := ""
-			if rm.generator != nil {
-				requestID = rm.generator()
-			} else {
-				requestID = fmt.Sprintf("%d", time.Now().UnixNano())
+			requestID, ok := RequestIDFromContext(ctx.Request.Context())
+			if !ok {
+				if rm.generator != nil {
+					requestID = rm.generator()
+				} else {
+					requestID = fmt.Sprintf("%d", time.Now().UnixNano())
+				}
+				ctx.Request = ctx.Request.WithContext(WithRequestID(ctx.Request.Context(), requestID))
 			}
 
 			ctx.Request.Header.Set("X-Request-ID", requestID)
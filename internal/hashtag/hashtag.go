@@ -0,0 +1,79 @@
+// Package hashtag 按 Redis Cluster 的规则计算 key 的 hash tag 和 slot，
+// 用于把一个脚本访问的多个 key 放到同一个 slot
+package hashtag
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SlotCount Redis Cluster 的 slot 数量
+const SlotCount = 16384
+
+// Key 返回 Redis 计算 slot 时使用的部分：key 中第一个 { 和它之后第一个 } 之间的内容不为空时
+// 取这部分，否则取整个 key
+func Key(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// Slot 返回 key 所在的 slot
+func Slot(key string) int {
+	return int(crc16(Key(key)) % SlotCount)
+}
+
+// SameSlot 生成和 key 落在同一个 slot 的派生 key，不同的 key 生成的派生 key 不会相同
+//
+// key 中有 hash tag 时直接在后面加 suffix。没有 hash tag 时 Redis 按整个 key 计算 slot，
+// 和 "{key}" 的结果相同，所以派生 key 写成 "{key}" + suffix，原来的 key 不需要改名。
+// 但 key 中含有 } 时包裹后的 tag 会在这个 } 处截断，这时改用一个和 key 同 slot 的短 tag，
+// 写成 "{tag}" + key + suffix。
+func SameSlot(key, suffix string) string {
+	if Key(key) != key {
+		return key + suffix
+	}
+	if key != "" && !strings.Contains(key, "}") {
+		return "{" + key + "}" + suffix
+	}
+	return "{" + slotTag(Slot(key)) + "}" + key + suffix
+}
+
+var (
+	slotTagsOnce sync.Once
+	slotTags     [SlotCount]string
+)
+
+// slotTag 返回一个落在 slot 中的短字符串，第一次调用时生成所有 slot 的对照表
+func slotTag(slot int) string {
+	slotTagsOnce.Do(func() {
+		for i, found := 0, 0; found < SlotCount; i++ {
+			tag := strconv.FormatInt(int64(i), 36)
+			if s := crc16(tag) % SlotCount; slotTags[s] == "" {
+				slotTags[s] = tag
+				found++
+			}
+		}
+	})
+	return slotTags[slot]
+}
+
+// crc16 Redis Cluster 使用的 CRC16-CCITT（XMODEM）
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
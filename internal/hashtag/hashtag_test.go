@@ -0,0 +1,54 @@
+package hashtag
+
+import "testing"
+
+func TestSlot(t *testing.T) {
+	// 和 Redis CLUSTER KEYSLOT 的结果对照
+	cases := map[string]int{
+		"123456789":            12739,
+		"foo":                  12182,
+		"bar":                  5061,
+		"{user1000}.following": 3443,
+		"user1000":             3443,
+		"foo{}{bar}":           8363,
+		"foo{{bar}}zap":        4015,
+		"foo{bar}{zap}":        5061,
+	}
+	for key, want := range cases {
+		if got := Slot(key); got != want {
+			t.Errorf("Slot(%q) = %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestSameSlot(t *testing.T) {
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{key: "user:1", want: "{user:1}:total"},
+		{key: "{tenant}:user:1", want: "{tenant}:user:1:total"},
+		{key: "a{b", want: "{a{b}:total"},
+		{key: "a{}b"},
+		{key: "a}b"},
+		{key: "}"},
+		{key: ""},
+	}
+	for _, c := range cases {
+		got := SameSlot(c.key, ":total")
+		if c.want != "" && got != c.want {
+			t.Errorf("SameSlot(%q) = %q, want %q", c.key, got, c.want)
+		}
+		if Slot(got) != Slot(c.key) {
+			t.Errorf("SameSlot(%q) = %q is in slot %d, want %d", c.key, got, Slot(got), Slot(c.key))
+		}
+	}
+}
+
+func TestSlotTagCoversAllSlots(t *testing.T) {
+	for slot := 0; slot < SlotCount; slot++ {
+		if tag := slotTag(slot); tag == "" || Slot(tag) != slot {
+			t.Fatalf("slotTag(%d) = %q", slot, tag)
+		}
+	}
+}
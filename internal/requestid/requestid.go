@@ -0,0 +1,20 @@
+// Package requestid 在 context 中保存请求 ID。
+//
+// httpclient 写入、logger 读取，放在不依赖任何其他包的叶子包里，
+// 两个包都不需要互相导入。
+package requestid
+
+import "context"
+
+type key struct{}
+
+// With 返回带有请求 ID 的 context
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// From 返回 With 写入的请求 ID，没有或者为空时返回 false
+func From(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(key{}).(string)
+	return id, ok && id != ""
+}
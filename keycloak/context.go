@@ -0,0 +1,17 @@
+package keycloak
+
+import "context"
+
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying verified claims, typically set by
+// an authentication middleware after VerifyAccessToken.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by WithClaims.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config describes a zap-backed Logger built by New.
+//
+// The zero value writes JSON to stderr at info level with the caller and,
+// for errors, a stack trace, like zap.NewProduction without sampling.
+type Config struct {
+	// Outputs lists where entries are written. Each entry goes to every
+	// output whose level it passes. Without outputs, entries go to stderr.
+	Outputs []OutputConfig `json:"outputs" yaml:"outputs"`
+
+	// Level is the default level. Modules overrides it for named loggers,
+	// see Logger.Named. Both can be changed at runtime through the Levels
+	// of the returned logger.
+	Level   Level            `json:"level" yaml:"level"`
+	Modules map[string]Level `json:"modules" yaml:"modules"`
+
+	// Encoding is "json" (default) or "console".
+	Encoding string `json:"encoding" yaml:"encoding"`
+
+	// Development records stack traces from warn instead of error.
+	Development       bool `json:"development" yaml:"development"`
+	DisableCaller     bool `json:"disable_caller" yaml:"disable_caller"`
+	DisableStacktrace bool `json:"disable_stacktrace" yaml:"disable_stacktrace"`
+
+	// Redact masks passwords, tokens, mobile and ID card numbers, bank
+	// cards, emails and JWTs with DefaultRedactor. Use WithRedactor for
+	// custom rules.
+	Redact bool `json:"redact" yaml:"redact"`
+}
+
+// OutputConfig describes one destination of a Logger built by New.
+type OutputConfig struct {
+	// Path is "stdout", "stderr" or a file path. Files are created with
+	// their directory and rotated according to Rotate.
+	Path string `json:"path" yaml:"path"`
+	// Encoding overrides Config.Encoding for this output.
+	Encoding string `json:"encoding" yaml:"encoding"`
+	// Level, when set, is the minimum level written to this output, for
+	// example ErrorLevel for a separate error file. Entries must also pass
+	// the logger levels.
+	Level *Level `json:"level" yaml:"level"`
+	// Rotate controls file rotation and retention. It is ignored for
+	// stdout and stderr.
+	Rotate RotateConfig `json:"rotate" yaml:"rotate"`
+}
+
+// New builds a Logger from cfg. Files opened for the outputs are closed by
+// ZapLogger.Close. opts are applied after the options derived from cfg.
+func New(cfg Config, opts ...Option) (*ZapLogger, error) {
+	levels := NewLevels(cfg.Level)
+	for module, level := range cfg.Modules {
+		levels.SetModuleLevel(module, level)
+	}
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []OutputConfig{{Path: "stderr"}}
+	}
+
+	var (
+		cores   []zapcore.Core
+		closers []io.Closer
+	)
+	for _, out := range outputs {
+		core, closer, err := newCore(cfg, out, levels)
+		if err != nil {
+			for _, c := range closers {
+				_ = c.Close()
+			}
+			return nil, err
+		}
+		cores = append(cores, core)
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	loggerOpts := []Option{WithLevels(levels)}
+	if cfg.Redact {
+		loggerOpts = append(loggerOpts, WithRedactor(DefaultRedactor()))
+	}
+	l := NewZapLogger(zap.New(zapcore.NewTee(cores...), cfg.zapOptions()...), append(loggerOpts, opts...)...)
+	l.closers = closers
+	return l, nil
+}
+
+func newCore(cfg Config, out OutputConfig, levels *Levels) (zapcore.Core, io.Closer, error) {
+	encoding := out.Encoding
+	if encoding == "" {
+		encoding = cfg.Encoding
+	}
+	enc, err := newEncoder(encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		ws     zapcore.WriteSyncer
+		closer io.Closer
+	)
+	switch out.Path {
+	case "stdout":
+		ws = zapcore.Lock(os.Stdout)
+	case "stderr":
+		ws = zapcore.Lock(os.Stderr)
+	case "":
+		return nil, nil, errors.New("logger: output path is required")
+	default:
+		f, err := NewRotatingFile(out.Path, out.Rotate)
+		if err != nil {
+			return nil, nil, err
+		}
+		ws, closer = f, f
+	}
+
+	enabler := levels.zapEnabler()
+	if out.Level != nil {
+		minLevel, base := out.Level.zapLevel(), enabler
+		enabler = zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+			return level >= minLevel && base.Enabled(level)
+		})
+	}
+	return zapcore.NewCore(enc, ws, enabler), closer, nil
+}
+
+func (cfg Config) zapOptions() []zap.Option {
+	opts := []zap.Option{zap.ErrorOutput(zapcore.Lock(os.Stderr))}
+	if !cfg.DisableCaller {
+		// skip the ZapLogger method so the caller is the code that logs
+		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+	if !cfg.DisableStacktrace {
+		stackLevel := zap.ErrorLevel
+		if cfg.Development {
+			stackLevel = zap.WarnLevel
+		}
+		opts = append(opts, zap.AddStacktrace(stackLevel))
+	}
+	if cfg.Development {
+		opts = append(opts, zap.Development())
+	}
+	return opts
+}
+
+func newEncoder(encoding string) (zapcore.Encoder, error) {
+	switch encoding {
+	case "", "json":
+		return zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), nil
+	case "console":
+		return zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), nil
+	default:
+		return nil, fmt.Errorf("logger: unknown encoding %q", encoding)
+	}
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/linorwang/goaid/internal/requestid"
+)
+
+type loggerKey struct{}
+
+type fieldsKey struct{}
+
+// ContextExtractor returns fields derived from values stored in ctx, such as
+// a trace ID or the authenticated user.
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractorsMu sync.Mutex
+	extractors   atomic.Pointer[[]ContextExtractor]
+)
+
+// The request ID set by httpclient.WithRequestID or RequestIDMiddleware is
+// read through a leaf package, so logger does not depend on httpclient.
+func init() {
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		if id, ok := requestid.From(ctx); ok {
+			return []Field{String("request_id", id)}
+		}
+		return nil
+	})
+}
+
+// RegisterContextExtractor registers fn to add fields to every log line
+// written through a *Context method. It is meant to be called during
+// program initialization.
+func RegisterContextExtractor(fn ContextExtractor) {
+	if fn == nil {
+		return
+	}
+
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	var list []ContextExtractor
+	if old := extractors.Load(); old != nil {
+		list = append(list, *old...)
+	}
+	list = append(list, fn)
+	extractors.Store(&list)
+}
+
+// RegisterContextKey registers a context key whose value, when present, is
+// logged as field.
+func RegisterContextKey(key any, field string) {
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		if v := ctx.Value(key); v != nil {
+			return []Field{Any(field, v)}
+		}
+		return nil
+	})
+}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the Logger stored by NewContext, or DefaultLogger.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return DefaultLogger
+}
+
+// WithFields returns a copy of ctx carrying fields in addition to the fields
+// already stored in ctx.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	old, _ := ctx.Value(fieldsKey{}).([]Field)
+	merged := make([]Field, 0, len(old)+len(fields))
+	merged = append(merged, old...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// ContextFields returns the fields stored by WithFields followed by the
+// fields of the registered extractors.
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	list := extractors.Load()
+	if list == nil {
+		return fields
+	}
+	res := fields[:len(fields):len(fields)]
+	for _, fn := range *list {
+		res = append(res, fn(ctx)...)
+	}
+	return res
+}
+
+// withContext prepends the context fields to args.
+func withContext(ctx context.Context, args []Field) []Field {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return args
+	}
+	return append(fields[:len(fields):len(fields)], args...)
+}
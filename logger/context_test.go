@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/linorwang/goaid/internal/requestid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type tenantKey struct{}
+
+// restoreExtractors unregisters the extractors registered by the test when it
+// finishes.
+func restoreExtractors(t *testing.T) {
+	old := extractors.Load()
+	t.Cleanup(func() { extractors.Store(old) })
+}
+
+func TestContextFields(t *testing.T) {
+	restoreExtractors(t)
+	RegisterContextKey(tenantKey{}, "tenant")
+
+	ctx := WithFields(context.Background(), String("trace_id", "t-1"))
+	ctx = WithFields(ctx, String("user_id", "u-1"))
+	ctx = requestid.With(ctx, "req-1")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	got := map[string]any{}
+	for _, f := range ContextFields(ctx) {
+		got[f.Key] = f.Value
+	}
+	want := map[string]any{"trace_id": "t-1", "user_id": "u-1", "request_id": "req-1", "tenant": "acme"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("field %s = %v, want %v (all: %v)", k, got[k], v, got)
+		}
+	}
+
+	if fields := ContextFields(context.Background()); len(fields) != 0 {
+		t.Fatalf("expected no fields, got %v", fields)
+	}
+}
+
+func TestZapLoggerContext(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := NewZapLogger(zap.New(core))
+
+	ctx := NewContext(context.Background(), l)
+	ctx = WithFields(ctx, String("trace_id", "t-1"))
+	InfoContext(ctx, "handled", Int("status", 200))
+	l.Info("plain")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["trace_id"] != "t-1" || fields["status"] != int64(200) {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+	if _, ok := entries[1].ContextMap()["trace_id"]; ok {
+		t.Fatal("plain methods must not add context fields")
+	}
+}
+
+func TestSlogContext(t *testing.T) {
+	ctx := WithFields(context.Background(), String("trace_id", "t-1"))
+
+	var buf bytes.Buffer
+	NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))).InfoContext(ctx, "handled")
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry["trace_id"] != "t-1" {
+		t.Fatalf("unexpected entry %q: %v", buf.String(), err)
+	}
+
+	// context fields are added once when a SlogLogger wraps a SlogHandler
+	core, logs := observer.New(zap.DebugLevel)
+	bridged := NewSlogLogger(slog.New(NewSlogHandler(NewZapLogger(zap.New(core)))))
+	bridged.InfoContext(ctx, "bridged")
+	slog.New(NewSlogHandler(NewZapLogger(zap.New(core)))).InfoContext(ctx, "from slog")
+
+	for _, e := range logs.All() {
+		if len(e.Context) != 1 || e.ContextMap()["trace_id"] != "t-1" {
+			t.Fatalf("%s: unexpected fields %v", e.Message, e.Context)
+		}
+	}
+}
@@ -1,16 +1,16 @@
 package logger
 
 import (
-	"go.uber.org/zap"
+	"context"
+	"net/http"
 )
 
 // DefaultLogger is the default logger instance
 var DefaultLogger Logger
 
 func init() {
-	// Initialize with production config by default
-	zapLogger, _ := zap.NewProduction()
-	DefaultLogger = NewZapLogger(zapLogger)
+	// Initialize with production-like settings by default
+	DefaultLogger, _ = New(Config{})
 }
 
 // SetDefault sets the default logger instance
@@ -18,36 +18,113 @@ func SetDefault(l Logger) {
 	DefaultLogger = l
 }
 
+// Init builds the default logger from cfg
+func Init(cfg Config) error {
+	l, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	SetDefault(l)
+	return nil
+}
+
+// DefaultLevels returns the levels of the default logger, or nil if it was
+// not created by New or with WithLevels
+func DefaultLevels() *Levels {
+	if l, ok := DefaultLogger.(interface{ Levels() *Levels }); ok {
+		return l.Levels()
+	}
+	return nil
+}
+
+// SetLevel changes the default level of the default logger
+func SetLevel(level Level) {
+	if levels := DefaultLevels(); levels != nil {
+		levels.SetLevel(level)
+	}
+}
+
+// SetModuleLevel changes the level of a module of the default logger
+func SetModuleLevel(module string, level Level) {
+	if levels := DefaultLevels(); levels != nil {
+		levels.SetModuleLevel(module, level)
+	}
+}
+
+// LevelHandler returns an http.Handler that reports and changes the levels of
+// the default logger, see Levels.ServeHTTP
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		levels := DefaultLevels()
+		if levels == nil {
+			writeLevels(w, http.StatusNotFound, "default logger has no levels", nil)
+			return
+		}
+		levels.ServeHTTP(w, r)
+	})
+}
+
+// pkgLogger returns l adjusted for being called from a package-level
+// function, so the reported caller is the code that called the function.
+func pkgLogger(l Logger) Logger {
+	if p, ok := l.(interface{ pkgLogger() Logger }); ok {
+		return p.pkgLogger()
+	}
+	return l
+}
+
 // Global convenience functions using DefaultLogger
 
 func Debug(msg string, args ...Field) {
-	DefaultLogger.Debug(msg, args...)
+	pkgLogger(DefaultLogger).Debug(msg, args...)
 }
 
 func Info(msg string, args ...Field) {
-	DefaultLogger.Info(msg, args...)
+	pkgLogger(DefaultLogger).Info(msg, args...)
 }
 
 func Warn(msg string, args ...Field) {
-	DefaultLogger.Warn(msg, args...)
+	pkgLogger(DefaultLogger).Warn(msg, args...)
 }
 
 func Error(msg string, args ...Field) {
-	DefaultLogger.Error(msg, args...)
+	pkgLogger(DefaultLogger).Error(msg, args...)
 }
 
 func Fatal(msg string, args ...Field) {
-	DefaultLogger.Fatal(msg, args...)
+	pkgLogger(DefaultLogger).Fatal(msg, args...)
 }
 
 func Panic(msg string, args ...Field) {
-	DefaultLogger.Panic(msg, args...)
+	pkgLogger(DefaultLogger).Panic(msg, args...)
+}
+
+// Context-aware functions using the Logger stored in ctx, or DefaultLogger
+
+func DebugContext(ctx context.Context, msg string, args ...Field) {
+	pkgLogger(FromContext(ctx)).DebugContext(ctx, msg, args...)
+}
+
+func InfoContext(ctx context.Context, msg string, args ...Field) {
+	pkgLogger(FromContext(ctx)).InfoContext(ctx, msg, args...)
+}
+
+func WarnContext(ctx context.Context, msg string, args ...Field) {
+	pkgLogger(FromContext(ctx)).WarnContext(ctx, msg, args...)
+}
+
+func ErrorContext(ctx context.Context, msg string, args ...Field) {
+	pkgLogger(FromContext(ctx)).ErrorContext(ctx, msg, args...)
 }
 
 func With(args ...Field) Logger {
 	return DefaultLogger.With(args...)
 }
 
+func Named(name string) Logger {
+	return DefaultLogger.Named(name)
+}
+
 func Sync() error {
 	return DefaultLogger.Sync()
 }
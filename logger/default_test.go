@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPackageFunctionsCaller(t *testing.T) {
+	old := DefaultLogger
+	t.Cleanup(func() { SetDefault(old) })
+
+	// same caller options as New
+	core, logs := observer.New(zap.DebugLevel)
+	l := NewZapLogger(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)))
+	SetDefault(l)
+	Info("package")
+	InfoContext(NewContext(context.Background(), l.With(String("k", "v"))), "context")
+	l.Info("method")
+
+	if logs.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", logs.Len())
+	}
+	for _, e := range logs.All() {
+		if !strings.HasSuffix(e.Caller.File, "default_test.go") {
+			t.Fatalf("%s: caller = %s, want default_test.go", e.Message, e.Caller.File)
+		}
+	}
+
+	var buf bytes.Buffer
+	SetDefault(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}))))
+	Warn("slog package")
+
+	var entry struct {
+		Source struct {
+			File string `json:"file"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if !strings.HasSuffix(entry.Source.File, "default_test.go") {
+		t.Fatalf("caller = %s, want default_test.go", entry.Source.File)
+	}
+}
@@ -0,0 +1,287 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/linorwang/goaid/resp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Level is a logging priority. Higher levels are more important.
+type Level int8
+
+const (
+	DebugLevel Level = iota - 1
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	PanicLevel
+	FatalLevel
+)
+
+// ParseLevel parses a level name such as "debug" or "WARN".
+func ParseLevel(s string) (Level, error) {
+	var l Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
+}
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case PanicLevel:
+		return "panic"
+	case FatalLevel:
+		return "fatal"
+	default:
+		return fmt.Sprintf("Level(%d)", l)
+	}
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses a level name, so Level can be used in JSON and YAML
+// configuration. An empty name means InfoLevel.
+func (l *Level) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = DebugLevel
+	case "info", "":
+		*l = InfoLevel
+	case "warn", "warning":
+		*l = WarnLevel
+	case "error":
+		*l = ErrorLevel
+	case "panic":
+		*l = PanicLevel
+	case "fatal":
+		*l = FatalLevel
+	default:
+		return fmt.Errorf("logger: unknown level %q", text)
+	}
+	return nil
+}
+
+func (l Level) zapLevel() zapcore.Level {
+	switch l {
+	case DebugLevel:
+		return zapcore.DebugLevel
+	case InfoLevel:
+		return zapcore.InfoLevel
+	case WarnLevel:
+		return zapcore.WarnLevel
+	case ErrorLevel:
+		return zapcore.ErrorLevel
+	case PanicLevel:
+		return zapcore.PanicLevel
+	default:
+		return zapcore.FatalLevel
+	}
+}
+
+// levelOf maps a slog level to the closest Level.
+func levelOf(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	case level < LevelPanic:
+		return ErrorLevel
+	case level < LevelFatal:
+		return PanicLevel
+	default:
+		return FatalLevel
+	}
+}
+
+// Levels holds a default level and per-module overrides. All of them can be
+// changed at runtime and take effect immediately for every logger sharing
+// the Levels.
+//
+// Module names are the names given to Logger.Named, joined with "." when
+// nested. A module without its own level uses the level of its closest
+// parent, so setting "sendsms" also applies to "sendsms.aliyun".
+type Levels struct {
+	level   atomic.Int32
+	min     atomic.Int32 // lowest of level and all module levels
+	mu      sync.Mutex
+	modules atomic.Pointer[map[string]Level]
+}
+
+// NewLevels creates Levels with the given default level.
+func NewLevels(level Level) *Levels {
+	l := &Levels{}
+	l.level.Store(int32(level))
+	l.min.Store(int32(level))
+	return l
+}
+
+// Level returns the default level.
+func (l *Levels) Level() Level {
+	return Level(l.level.Load())
+}
+
+// SetLevel changes the default level.
+func (l *Levels) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.level.Store(int32(level))
+	l.updateMin()
+}
+
+// ModuleLevel returns the level in effect for module.
+func (l *Levels) ModuleLevel(module string) Level {
+	if modules := l.modules.Load(); modules != nil && module != "" {
+		for name := module; ; {
+			if level, ok := (*modules)[name]; ok {
+				return level
+			}
+			i := strings.LastIndexByte(name, '.')
+			if i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+	}
+	return l.Level()
+}
+
+// SetModuleLevel sets the level of module and its sub-modules.
+func (l *Levels) SetModuleLevel(module string, level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	modules := l.copyModules()
+	modules[module] = level
+	l.modules.Store(&modules)
+	l.updateMin()
+}
+
+// UnsetModuleLevel removes the level of module, which then uses the level of
+// its parent or the default level again.
+func (l *Levels) UnsetModuleLevel(module string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	modules := l.copyModules()
+	delete(modules, module)
+	l.modules.Store(&modules)
+	l.updateMin()
+}
+
+// Modules returns a copy of the module levels.
+func (l *Levels) Modules() map[string]Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.copyModules()
+}
+
+// Enabled reports whether module logs at level.
+func (l *Levels) Enabled(module string, level Level) bool {
+	if level < Level(l.min.Load()) {
+		return false
+	}
+	return level >= l.ModuleLevel(module)
+}
+
+// zapEnabler returns a zapcore.LevelEnabler for the lowest configured level,
+// so that the zap core passes every entry that some module may log. The
+// final decision is made per logger by Levels.Enabled.
+func (l *Levels) zapEnabler() zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+		return level >= Level(l.min.Load()).zapLevel()
+	})
+}
+
+// levelsBody is the request and response body of Levels.ServeHTTP.
+type levelsBody struct {
+	Level   *Level           `json:"level,omitempty"`
+	Module  string           `json:"module,omitempty"`
+	Modules map[string]Level `json:"modules,omitempty"`
+}
+
+// ServeHTTP reports and changes levels over HTTP.
+//
+//	GET                                            current default and module levels
+//	PUT {"level": "debug"}                         change the default level
+//	PUT {"module": "sendsms", "level": "debug"}    change the level of a module
+//	PUT {"module": "sendsms"}                      remove the level of a module
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var body levelsBody
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&body); err != nil {
+			writeLevels(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		switch {
+		case body.Module != "" && body.Level == nil:
+			l.UnsetModuleLevel(body.Module)
+		case body.Module != "":
+			l.SetModuleLevel(body.Module, *body.Level)
+		case body.Level != nil:
+			l.SetLevel(*body.Level)
+		default:
+			writeLevels(w, http.StatusBadRequest, "level or module is required", nil)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevels(w, http.StatusMethodNotAllowed, "method not allowed", nil)
+		return
+	}
+
+	level := l.Level()
+	writeLevels(w, http.StatusOK, "ok", levelsBody{Level: &level, Modules: l.Modules()})
+}
+
+func writeLevels(w http.ResponseWriter, status int, msg string, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp.Result{Code: status, Data: data, Msg: msg})
+}
+
+// copyModules returns a mutable copy of the module levels. The caller must
+// hold l.mu.
+func (l *Levels) copyModules() map[string]Level {
+	res := make(map[string]Level)
+	if modules := l.modules.Load(); modules != nil {
+		for name, level := range *modules {
+			res[name] = level
+		}
+	}
+	return res
+}
+
+// updateMin recomputes the lowest level. The caller must hold l.mu.
+func (l *Levels) updateMin() {
+	low := l.Level()
+	if modules := l.modules.Load(); modules != nil {
+		for _, level := range *modules {
+			low = min(low, level)
+		}
+	}
+	l.min.Store(int32(low))
+}
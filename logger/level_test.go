@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, PanicLevel, FatalLevel} {
+		got, err := ParseLevel(strings.ToUpper(level.String()))
+		if err != nil || got != level {
+			t.Fatalf("ParseLevel(%q) = %v, %v", level, got, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"level":"warn","modules":{"sendsms":"debug"}}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if cfg.Level != WarnLevel || cfg.Modules["sendsms"] != DebugLevel {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	levels := NewLevels(InfoLevel)
+	levels.SetModuleLevel("sendsms", DebugLevel)
+	levels.SetModuleLevel("sendsms.aliyun", ErrorLevel)
+
+	tests := []struct {
+		module string
+		want   Level
+	}{
+		{module: "", want: InfoLevel},
+		{module: "pay", want: InfoLevel},
+		{module: "sendsms", want: DebugLevel},
+		{module: "sendsms.tencent", want: DebugLevel},
+		{module: "sendsms.aliyun.client", want: ErrorLevel},
+		{module: "sendsmsx", want: InfoLevel},
+	}
+	for _, tt := range tests {
+		if got := levels.ModuleLevel(tt.module); got != tt.want {
+			t.Errorf("ModuleLevel(%q) = %v, want %v", tt.module, got, tt.want)
+		}
+	}
+
+	levels.UnsetModuleLevel("sendsms")
+	if got := levels.ModuleLevel("sendsms.tencent"); got != InfoLevel {
+		t.Fatalf("expected default level after unset, got %v", got)
+	}
+}
+
+func TestZapLoggerLevels(t *testing.T) {
+	levels := NewLevels(InfoLevel)
+	core, logs := observer.New(levels.zapEnabler())
+	root := NewZapLogger(zap.New(core), WithLevels(levels))
+	sms := root.Named("sendsms")
+
+	root.Debug("root debug")
+	sms.Debug("sms debug")
+
+	levels.SetModuleLevel("sendsms", DebugLevel)
+	root.Debug("root debug")
+	sms.With(String("provider", "aliyun")).Debug("sms debug")
+
+	levels.SetLevel(ErrorLevel)
+	root.Warn("root warn")
+	slog.New(NewSlogHandler(sms)).Debug("sms slog debug")
+
+	var got []string
+	for _, e := range logs.All() {
+		got = append(got, e.LoggerName+":"+e.Message)
+	}
+	want := []string{"sendsms:sms debug", "sendsms:sms slog debug"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("logged %v, want %v", got, want)
+	}
+}
+
+func TestSlogLoggerLevels(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(InfoLevel)
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), WithLevels(levels))
+
+	l.Debug("hidden")
+	levels.SetModuleLevel("pay", DebugLevel)
+	l.Named("pay").Debug("shown")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "shown" || entry["logger"] != "pay" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+}
+
+func TestSlogBridgeLevels(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(ErrorLevel)
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), WithLevels(levels))
+
+	bridged := slog.New(NewSlogHandler(l))
+	bridged.Info("hidden")
+	slog.New(NewSlogHandler(l.Named("pay"))).Warn("hidden")
+	levels.SetModuleLevel("pay", DebugLevel)
+	slog.New(NewSlogHandler(l.Named("pay"))).Info("shown")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "shown" || entry["logger"] != "pay" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+}
+
+func TestLevelsServeHTTP(t *testing.T) {
+	levels := NewLevels(InfoLevel)
+
+	do := func(method, body string) (int, levelsBody) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		levels.ServeHTTP(rec, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+		var res struct {
+			Data levelsBody `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res.Data
+	}
+
+	if code, _ := do(http.MethodPut, `{"level":"debug"}`); code != http.StatusOK || levels.Level() != DebugLevel {
+		t.Fatalf("PUT level: code %d, level %v", code, levels.Level())
+	}
+	if code, _ := do(http.MethodPut, `{"module":"sendsms","level":"error"}`); code != http.StatusOK {
+		t.Fatalf("PUT module: code %d", code)
+	}
+	code, body := do(http.MethodGet, "")
+	if code != http.StatusOK || *body.Level != DebugLevel || body.Modules["sendsms"] != ErrorLevel {
+		t.Fatalf("GET: code %d, body %+v", code, body)
+	}
+	if code, _ = do(http.MethodPut, `{"module":"sendsms"}`); code != http.StatusOK || len(levels.Modules()) != 0 {
+		t.Fatalf("unset module: code %d, modules %v", code, levels.Modules())
+	}
+
+	for _, body := range []string{`{"level":"loud"}`, `{}`, `not json`} {
+		if code, _ = do(http.MethodPut, body); code != http.StatusBadRequest {
+			t.Fatalf("PUT %s: expected 400, got %d", body, code)
+		}
+	}
+	if code, _ = do(http.MethodDelete, ""); code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE: expected 405, got %d", code)
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	l, err := New(Config{Level: WarnLevel, Modules: map[string]Level{"sendsms": DebugLevel}, Encoding: "console"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if !l.Named("sendsms").(*ZapLogger).enabled(DebugLevel) || l.enabled(InfoLevel) {
+		t.Fatal("unexpected levels")
+	}
+	if !l.l.Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("core must pass the lowest module level")
+	}
+
+	if _, err = New(Config{Encoding: "xml"}); err == nil {
+		t.Fatal("expected error for unknown encoding")
+	}
+}
@@ -1,49 +1,112 @@
 package logger
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 )
 
 type ZapLogger struct {
-	l *zap.Logger
+	l        *zap.Logger
+	levels   *Levels
+	module   string
+	redactor *Redactor
+	closers  []io.Closer // files opened by New
+
+	pkg atomic.Pointer[ZapLogger] // see pkgLogger
 }
 
-func NewZapLogger(l *zap.Logger) *ZapLogger {
+func NewZapLogger(l *zap.Logger, opts ...Option) *ZapLogger {
+	o := applyOptions(opts)
 	return &ZapLogger{
-		l: l,
+		l:        l,
+		levels:   o.levels,
+		redactor: o.redactor,
 	}
 }
 
 func (z *ZapLogger) Debug(msg string, args ...Field) {
-	z.l.Debug(msg, z.toArgs(args)...)
+	if z.enabled(DebugLevel) {
+		z.l.Debug(z.redactMsg(msg), z.toArgs(args)...)
+	}
 }
 
 func (z *ZapLogger) Info(msg string, args ...Field) {
-	z.l.Info(msg, z.toArgs(args)...)
+	if z.enabled(InfoLevel) {
+		z.l.Info(z.redactMsg(msg), z.toArgs(args)...)
+	}
 }
 
 func (z *ZapLogger) Warn(msg string, args ...Field) {
-	z.l.Warn(msg, z.toArgs(args)...)
+	if z.enabled(WarnLevel) {
+		z.l.Warn(z.redactMsg(msg), z.toArgs(args)...)
+	}
 }
 
 func (z *ZapLogger) Error(msg string, args ...Field) {
-	z.l.Error(msg, z.toArgs(args)...)
+	if z.enabled(ErrorLevel) {
+		z.l.Error(z.redactMsg(msg), z.toArgs(args)...)
+	}
 }
 
 func (z *ZapLogger) Fatal(msg string, args ...Field) {
-	z.l.Fatal(msg, z.toArgs(args)...)
+	z.l.Fatal(z.redactMsg(msg), z.toArgs(args)...)
 }
 
 func (z *ZapLogger) Panic(msg string, args ...Field) {
-	z.l.Panic(msg, z.toArgs(args)...)
+	z.l.Panic(z.redactMsg(msg), z.toArgs(args)...)
+}
+
+func (z *ZapLogger) DebugContext(ctx context.Context, msg string, args ...Field) {
+	if z.enabled(DebugLevel) {
+		z.l.Debug(z.redactMsg(msg), z.toArgs(withContext(ctx, args))...)
+	}
+}
+
+func (z *ZapLogger) InfoContext(ctx context.Context, msg string, args ...Field) {
+	if z.enabled(InfoLevel) {
+		z.l.Info(z.redactMsg(msg), z.toArgs(withContext(ctx, args))...)
+	}
+}
+
+func (z *ZapLogger) WarnContext(ctx context.Context, msg string, args ...Field) {
+	if z.enabled(WarnLevel) {
+		z.l.Warn(z.redactMsg(msg), z.toArgs(withContext(ctx, args))...)
+	}
+}
+
+func (z *ZapLogger) ErrorContext(ctx context.Context, msg string, args ...Field) {
+	if z.enabled(ErrorLevel) {
+		z.l.Error(z.redactMsg(msg), z.toArgs(withContext(ctx, args))...)
+	}
 }
 
 func (z *ZapLogger) With(args ...Field) Logger {
 	zapArgs := z.toArgs(args)
 	return &ZapLogger{
-		l: z.l.With(zapArgs...),
+		l:        z.l.With(zapArgs...),
+		levels:   z.levels,
+		module:   z.module,
+		redactor: z.redactor,
+		closers:  z.closers,
+	}
+}
+
+// Named returns a sub-logger for a module. The name is joined to the names
+// of the parent loggers with "." and, when the logger was created with
+// WithLevels, selects the module level.
+func (z *ZapLogger) Named(name string) Logger {
+	return &ZapLogger{
+		l:        z.l.Named(name),
+		levels:   z.levels,
+		module:   joinName(z.module, name),
+		redactor: z.redactor,
+		closers:  z.closers,
 	}
 }
 
@@ -51,16 +114,71 @@ func (z *ZapLogger) Sync() error {
 	return z.l.Sync()
 }
 
+// Close closes the files opened by New. Loggers derived with With or Named
+// share the files, so Close should be called once, when the application
+// shuts down.
+func (z *ZapLogger) Close() error {
+	var errs []error
+	for _, c := range z.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Enabled reports whether the logger and its zap core handle level.
+func (z *ZapLogger) Enabled(_ context.Context, level slog.Level) bool {
+	l := bridgeLevel(level)
+	return z.enabled(l) && z.l.Core().Enabled(l.zapLevel())
+}
+
+// Levels returns the levels set by WithLevels, or nil.
+func (z *ZapLogger) Levels() *Levels {
+	return z.levels
+}
+
+// pkgLogger returns a copy of z that skips one more caller frame, used by the
+// package-level functions such as Info so the caller is their caller.
+func (z *ZapLogger) pkgLogger() Logger {
+	if p := z.pkg.Load(); p != nil {
+		return p
+	}
+	p := &ZapLogger{
+		l:        z.l.WithOptions(zap.AddCallerSkip(1)),
+		levels:   z.levels,
+		module:   z.module,
+		redactor: z.redactor,
+		closers:  z.closers,
+	}
+	z.pkg.Store(p)
+	return p
+}
+
+func (z *ZapLogger) enabled(level Level) bool {
+	return z.levels == nil || z.levels.Enabled(z.module, level)
+}
+
+func (z *ZapLogger) redactMsg(msg string) string {
+	if z.redactor == nil {
+		return msg
+	}
+	return z.redactor.RedactString(msg)
+}
+
 func (z *ZapLogger) toArgs(args []Field) []zap.Field {
 	if len(args) == 0 {
 		return nil
 	}
+	if z.redactor != nil {
+		args = z.redactor.Redact(args)
+	}
 
 	res := make([]zap.Field, 0, len(args))
 	for _, arg := range args {
 		switch v := arg.Value.(type) {
 		case string:
 			res = append(res, zap.String(arg.Key, v))
+		case SecretString:
+			res = append(res, zap.String(arg.Key, v.String()))
 		case int:
 			res = append(res, zap.Int(arg.Key, v))
 		case int64:
@@ -76,7 +194,11 @@ func (z *ZapLogger) toArgs(args []Field) []zap.Field {
 		case time.Duration:
 			res = append(res, zap.Duration(arg.Key, v))
 		case error:
-			res = append(res, zap.Error(v))
+			if arg.Key == "" {
+				res = append(res, zap.Error(v))
+			} else {
+				res = append(res, zap.NamedError(arg.Key, v))
+			}
 		default:
 			res = append(res, zap.Any(arg.Key, v))
 		}
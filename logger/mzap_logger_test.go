@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerErrorFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	err := errors.New("file does not exist")
+	NewZapLogger(zap.New(core)).Error("failed", Err(err), Field{Value: err}, Any("cause", err))
+
+	fields := logs.All()[0].ContextMap()
+	if fields["error"] != err.Error() || fields["cause"] != err.Error() {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+	if _, ok := fields[""]; ok {
+		t.Fatalf("an error without a key must be logged as error: %v", fields)
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+// Option configures a ZapLogger or SlogLogger.
+type Option func(*options)
+
+type options struct {
+	levels   *Levels
+	redactor *Redactor
+}
+
+// WithLevels filters log entries by levels, which can be changed at runtime
+// and may differ per module (see Logger.Named). Without it, filtering is left
+// to the underlying zap core or slog handler.
+func WithLevels(levels *Levels) Option {
+	return func(o *options) {
+		o.levels = levels
+	}
+}
+
+// WithRedactor masks sensitive fields and message parts with r before they
+// are logged. Fields created with Secret are always masked, with or without
+// a Redactor.
+func WithRedactor(r *Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// joinName appends name to a module path.
+func joinName(module, name string) string {
+	if module == "" {
+		return name
+	}
+	if name == "" {
+		return module
+	}
+	return module + "." + name
+}
@@ -0,0 +1,362 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// masked is what a fully masked value renders as.
+const masked = "******"
+
+// Masker masks a sensitive value.
+type Masker func(s string) string
+
+// MaskAll hides the whole value.
+func MaskAll(string) string {
+	return masked
+}
+
+// MaskMobile keeps the first 3 and last 4 characters of a mobile number,
+// e.g. 138****5678.
+func MaskMobile(s string) string {
+	return maskMiddle(s, 3, 4)
+}
+
+// MaskIDCard keeps the first 3 and last 4 characters of an ID card number,
+// e.g. 110***********123X.
+func MaskIDCard(s string) string {
+	return maskMiddle(s, 3, 4)
+}
+
+// MaskBankCard keeps the first 6 and last 4 digits of a bank card number.
+func MaskBankCard(s string) string {
+	return maskMiddle(s, 6, 4)
+}
+
+// MaskEmail keeps the first character of the local part and the domain,
+// e.g. a***@example.com.
+func MaskEmail(s string) string {
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok || local == "" {
+		return masked
+	}
+	r, _ := utf8.DecodeRuneInString(local)
+	return string(r) + "***@" + domain
+}
+
+// MaskJWT keeps the header of a JWT and hides the claims and signature.
+func MaskJWT(s string) string {
+	header, _, ok := strings.Cut(s, ".")
+	if !ok {
+		return masked
+	}
+	return header + "." + masked
+}
+
+// maskBearer keeps the scheme of an Authorization value.
+func maskBearer(s string) string {
+	scheme, _, ok := strings.Cut(s, " ")
+	if !ok {
+		return masked
+	}
+	return scheme + " " + masked
+}
+
+// maskLuhn masks card-like numbers that pass the Luhn check and leaves other
+// long numbers, such as timestamps and IDs, unchanged.
+func maskLuhn(s string) string {
+	if !luhnValid(s) {
+		return s
+	}
+	return MaskBankCard(s)
+}
+
+// maskIDCardChecked masks ID card numbers with a valid GB 11643 check digit
+// and leaves other 18-digit numbers, such as order numbers, unchanged.
+func maskIDCardChecked(s string) string {
+	if !idCardValid(s) {
+		return s
+	}
+	return MaskIDCard(s)
+}
+
+func maskMiddle(s string, head, tail int) string {
+	runes := []rune(s)
+	if len(runes) <= head+tail {
+		return masked
+	}
+	return string(runes[:head]) + strings.Repeat("*", len(runes)-head-tail) + string(runes[len(runes)-tail:])
+}
+
+func luhnValid(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+var idCardWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// idCardValid reports whether s is an 18-character ID card number whose last
+// character matches the GB 11643 check digit.
+func idCardValid(s string) bool {
+	if len(s) != 18 {
+		return false
+	}
+	sum := 0
+	for i, w := range idCardWeights {
+		d := int(s[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		sum += d * w
+	}
+	check := "10X98765432"[sum%11]
+	return s[17] == check || check == 'X' && s[17] == 'x'
+}
+
+// SecretString is a string that always renders masked, whether it is logged
+// as a field, encoded to JSON or formatted with fmt. Use it for struct fields
+// such as passwords that may end up in logs through Struct or Any.
+type SecretString string
+
+func (s SecretString) String() string {
+	return masked
+}
+
+func (s SecretString) GoString() string {
+	return `"` + masked + `"`
+}
+
+func (s SecretString) MarshalText() ([]byte, error) {
+	return []byte(masked), nil
+}
+
+// LogValue implements slog.LogValuer.
+func (s SecretString) LogValue() slog.Value {
+	return slog.StringValue(masked)
+}
+
+// Secret constructs a field whose value is always masked.
+func Secret(key, val string) Field {
+	return Field{
+		Key:   key,
+		Value: SecretString(val),
+	}
+}
+
+// RedactRule masks sensitive values. Values of fields whose key is in Keys
+// are masked entirely with Mask; parts of string values and messages
+// matching Pattern are replaced with Mask of the match.
+type RedactRule struct {
+	// Keys are matched case-insensitively and ignoring "_" and "-", so
+	// "access_token" also matches "accessToken". For dotted keys, such as
+	// slog groups, the last segment is matched as well.
+	Keys    []string
+	Pattern *regexp.Regexp
+	Mask    Masker
+}
+
+// DefaultRedactRules returns the built-in rules for secrets, tokens, Chinese
+// mobile and ID card numbers, bank cards, emails and JWTs.
+//
+// Fields named like an ID card or bank card number are always masked. In
+// free text, ID card numbers are only masked when the GB 11643 check digit is
+// valid and card numbers when they pass the Luhn check. These checks are
+// weak: about 1 in 11 random 18-digit numbers has a valid ID card check digit
+// and about 1 in 10 random 16–19 digit numbers, such as order or trace IDs,
+// passes Luhn, so such IDs in messages may still be masked.
+func DefaultRedactRules() []RedactRule {
+	return []RedactRule{
+		{
+			Keys: []string{"password", "passwd", "pwd", "secret", "token", "access_token", "refresh_token",
+				"id_token", "authorization", "cookie", "api_key", "secret_key", "client_secret",
+				"access_key_secret", "private_key"},
+			Mask: MaskAll,
+		},
+		{
+			Keys:    []string{"jwt"},
+			Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+			Mask:    MaskJWT,
+		},
+		{
+			Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`),
+			Mask:    maskBearer,
+		},
+		{
+			Keys: []string{"id_card", "id_no", "id_number"},
+			Mask: MaskIDCard,
+		},
+		{
+			Pattern: regexp.MustCompile(`\b\d{17}[\dXx]\b`),
+			Mask:    maskIDCardChecked,
+		},
+		{
+			Keys: []string{"bank_card", "card_no", "card_number"},
+			Mask: MaskBankCard,
+		},
+		{
+			Pattern: regexp.MustCompile(`\b\d{16,19}\b`),
+			Mask:    maskLuhn,
+		},
+		{
+			Keys:    []string{"phone", "mobile", "phone_number", "phone_numbers"},
+			Pattern: regexp.MustCompile(`\b1[3-9]\d{9}\b`),
+			Mask:    MaskMobile,
+		},
+		{
+			Keys:    []string{"email"},
+			Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+			Mask:    MaskEmail,
+		},
+	}
+}
+
+// Redactor masks sensitive fields and message parts according to rules.
+type Redactor struct {
+	keys     map[string]Masker
+	patterns []RedactRule
+}
+
+// NewRedactor creates a Redactor. When several rules name the same key, the
+// first one wins; patterns are applied in order.
+func NewRedactor(rules ...RedactRule) *Redactor {
+	r := &Redactor{keys: make(map[string]Masker)}
+	for _, rule := range rules {
+		if rule.Mask == nil {
+			rule.Mask = MaskAll
+		}
+		for _, key := range rule.Keys {
+			if k := normalizeKey(key); r.keys[k] == nil {
+				r.keys[k] = rule.Mask
+			}
+		}
+		if rule.Pattern != nil {
+			r.patterns = append(r.patterns, rule)
+		}
+	}
+	return r
+}
+
+// DefaultRedactor creates a Redactor with DefaultRedactRules.
+func DefaultRedactor() *Redactor {
+	return NewRedactor(DefaultRedactRules()...)
+}
+
+// RedactString masks the parts of s that match a pattern.
+func (r *Redactor) RedactString(s string) string {
+	for _, rule := range r.patterns {
+		s = rule.Pattern.ReplaceAllStringFunc(s, rule.Mask)
+	}
+	return s
+}
+
+// Redact returns fields with sensitive values masked. Strings, string
+// slices and errors are redacted; other values are only masked when their
+// key matches a rule, using their fmt representation. fields is not
+// modified.
+func (r *Redactor) Redact(fields []Field) []Field {
+	var res []Field
+	for i, f := range fields {
+		v, changed := r.redactField(f)
+		if !changed {
+			if res != nil {
+				res = append(res, f)
+			}
+			continue
+		}
+		if res == nil {
+			res = make([]Field, i, len(fields))
+			copy(res, fields[:i])
+		}
+		res = append(res, Field{Key: f.Key, Value: v})
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+func (r *Redactor) redactField(f Field) (any, bool) {
+	if _, ok := f.Value.(SecretString); ok || f.Value == nil {
+		return nil, false
+	}
+
+	if mask := r.keyMask(f.Key); mask != nil {
+		switch v := f.Value.(type) {
+		case []string:
+			res := make([]string, len(v))
+			for i, s := range v {
+				res[i] = mask(s)
+			}
+			return res, true
+		default:
+			return mask(fmt.Sprint(v)), true
+		}
+	}
+
+	if len(r.patterns) == 0 {
+		return nil, false
+	}
+	switch v := f.Value.(type) {
+	case string:
+		if s := r.RedactString(v); s != v {
+			return s, true
+		}
+	case []string:
+		var res []string
+		for i, s := range v {
+			if rs := r.RedactString(s); rs != s && res == nil {
+				res = append(make([]string, 0, len(v)), v[:i]...)
+				res = append(res, rs)
+			} else if res != nil {
+				res = append(res, rs)
+			}
+		}
+		if res != nil {
+			return res, true
+		}
+	case error:
+		if msg := v.Error(); r.RedactString(msg) != msg {
+			return r.RedactString(msg), true
+		}
+	}
+	return nil, false
+}
+
+func (r *Redactor) keyMask(key string) Masker {
+	if len(r.keys) == 0 {
+		return nil
+	}
+	if mask := r.keys[normalizeKey(key)]; mask != nil {
+		return mask
+	}
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return r.keys[normalizeKey(key[i+1:])]
+	}
+	return nil
+}
+
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(key))
+}
@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMaskers(t *testing.T) {
+	tests := []struct {
+		mask Masker
+		in   string
+		want string
+	}{
+		{mask: MaskAll, in: "p@ss", want: "******"},
+		{mask: MaskMobile, in: "13812345678", want: "138****5678"},
+		{mask: MaskMobile, in: "123", want: "******"},
+		{mask: MaskIDCard, in: "11010519491231002X", want: "110***********002X"},
+		{mask: MaskBankCard, in: "6222020200112233", want: "622202******2233"},
+		{mask: MaskEmail, in: "alice@example.com", want: "a***@example.com"},
+		{mask: MaskEmail, in: "alice", want: "******"},
+		{mask: MaskJWT, in: "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig", want: "eyJhbGciOiJIUzI1NiJ9.******"},
+	}
+	for _, tt := range tests {
+		if got := tt.mask(tt.in); got != tt.want {
+			t.Errorf("mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorRedact(t *testing.T) {
+	r := DefaultRedactor()
+	fields := []Field{
+		String("order_id", "o-1"),
+		String("Password", "p@ss"),
+		Int("accessToken", 42),
+		String("user.mobile", "13812345678"),
+		Strs("phone_numbers", []string{"13812345678", "13987654321"}),
+		String("note", "call 13812345678 or mail bob@example.com"),
+		Err(errors.New("card 4111111111111111 declined")),
+		String("trace", "1234567890123456"),              // not Luhn-valid
+		String("order", "order 110105194912310021 paid"), // wrong ID card check digit
+		String("id_no", "110105194912310021"),
+		String("auth", "Bearer abc.def"),
+		Secret("api_key", "k-1"),
+	}
+	got := r.Redact(fields)
+
+	want := map[string]any{
+		"order_id":      "o-1",
+		"Password":      "******",
+		"accessToken":   "******",
+		"user.mobile":   "138****5678",
+		"phone_numbers": []string{"138****5678", "139****4321"},
+		"note":          "call 138****5678 or mail b***@example.com",
+		"error":         "card 411111******1111 declined",
+		"trace":         "1234567890123456",
+		"order":         "order 110105194912310021 paid",
+		"id_no":         "110***********0021",
+		"auth":          "Bearer ******",
+		"api_key":       "******",
+	}
+	for _, f := range got {
+		if fmt.Sprint(f.Value) != fmt.Sprint(want[f.Key]) {
+			t.Errorf("%s = %v, want %v", f.Key, f.Value, want[f.Key])
+		}
+	}
+	if fields[1].Value != "p@ss" {
+		t.Fatal("Redact must not modify its argument")
+	}
+
+	clean := []Field{String("order_id", "o-1"), Int("status", 200)}
+	if res := r.Redact(clean); &res[0] != &clean[0] {
+		t.Fatal("expected fields without sensitive values to be returned as is")
+	}
+}
+
+func TestRedactorCustomRules(t *testing.T) {
+	r := NewRedactor(
+		RedactRule{Keys: []string{"openid"}},
+		RedactRule{Pattern: regexp.MustCompile(`sk-[a-z0-9]+`)},
+	)
+	got := r.Redact([]Field{String("open-id", "o123"), String("msg", "key sk-abc1 leaked"), String("mobile", "13812345678")})
+	if got[0].Value != "******" || got[1].Value != "key ****** leaked" || got[2].Value != "13812345678" {
+		t.Fatalf("unexpected fields: %v", got)
+	}
+}
+
+func TestSecretString(t *testing.T) {
+	type login struct {
+		User     string       `json:"user"`
+		Password SecretString `json:"password"`
+	}
+	v := login{User: "alice", Password: "p@ss"}
+
+	data, _ := json.Marshal(v)
+	if string(data) != `{"user":"alice","password":"******"}` {
+		t.Fatalf("json = %s", data)
+	}
+	for _, s := range []string{fmt.Sprint(v), fmt.Sprintf("%+v", v), fmt.Sprintf("%#v", v)} {
+		if strings.Contains(s, "p@ss") {
+			t.Fatalf("secret leaked in %q", s)
+		}
+	}
+
+	// secrets stay masked without a Redactor
+	core, logs := observer.New(zap.InfoLevel)
+	NewZapLogger(zap.New(core)).Info("login", Secret("password", "p@ss"), Struct("req", v))
+	entry := logs.All()[0].ContextMap()
+	if entry["password"] != "******" || strings.Contains(fmt.Sprint(entry["req"]), "p@ss") {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+
+	var buf bytes.Buffer
+	NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))).Info("login", Secret("password", "p@ss"))
+	if strings.Contains(buf.String(), "p@ss") {
+		t.Fatalf("secret leaked: %s", buf.String())
+	}
+}
+
+func TestZapLoggerRedact(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := NewZapLogger(zap.New(core), WithRedactor(DefaultRedactor()))
+
+	l.With(String("token", "t-1")).Named("sendsms").Info("send to 13812345678", String("phone", "13812345678"))
+	slog.New(NewSlogHandler(l)).Info("notify", slog.Group("card", "card_no", "6222020200112233"))
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	first := entries[0].ContextMap()
+	if entries[0].Message != "send to 138****5678" || first["token"] != "******" || first["phone"] != "138****5678" {
+		t.Fatalf("unexpected entry: %s %v", entries[0].Message, first)
+	}
+	if got := entries[1].ContextMap()["card.card_no"]; got != "622202******2233" {
+		t.Fatalf("card.card_no = %v", got)
+	}
+}
+
+func TestSlogLoggerRedact(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), WithRedactor(DefaultRedactor()))
+
+	l.With(String("email", "alice@example.com")).Info("id 11010519491231002X", String("password", "p@ss"))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "id 110***********002X" || entry["email"] != "a***@example.com" || entry["password"] != "******" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+}
+
+func TestNewRedact(t *testing.T) {
+	l, err := New(Config{Redact: true})
+	if err != nil || l.redactor == nil {
+		t.Fatalf("expected a redactor, got %v, %v", l, err)
+	}
+
+	custom := NewRedactor(RedactRule{Keys: []string{"openid"}})
+	if l, _ = New(Config{Redact: true}, WithRedactor(custom)); l.redactor != custom {
+		t.Fatal("options passed to New must override the config")
+	}
+}
+
+func TestIDCardValid(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{in: "11010519491231002X", want: true},
+		{in: "11010519491231002x", want: true},
+		{in: "110105194912310021", want: false},
+		{in: "123456789012345678", want: false},
+		{in: "1101051949123100", want: false},
+	}
+	for _, tt := range tests {
+		if got := idCardValid(tt.in); got != tt.want {
+			t.Errorf("idCardValid(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSlogBridgeRedact(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), WithRedactor(DefaultRedactor()))
+
+	slog.New(NewSlogHandler(l)).With("token", "t-1").Error("call 13812345678", "password", "hunter2", "phone", "13812345678")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "call 138****5678" || entry["password"] != "******" || entry["phone"] != "138****5678" ||
+		entry["token"] != "******" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+}
@@ -0,0 +1,372 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+	megabyte         = 1024 * 1024
+)
+
+// RotateConfig controls when a RotatingFile starts a new file and how long
+// rotated files are kept. The zero value never rotates.
+type RotateConfig struct {
+	// MaxSize is the size in megabytes at which the file is rotated.
+	// Zero disables size-based rotation.
+	MaxSize int `json:"max_size" yaml:"max_size"`
+	// Rotation is "hourly" or "daily" for time-based rotation at the start
+	// of each local hour or day. Empty disables time-based rotation.
+	Rotation string `json:"rotation" yaml:"rotation"`
+	// MaxAge is the number of days to keep rotated files. Zero keeps them
+	// regardless of age.
+	MaxAge int `json:"max_age" yaml:"max_age"`
+	// MaxBackups is the number of rotated files to keep. Zero keeps all.
+	MaxBackups int `json:"max_backups" yaml:"max_backups"`
+	// Compress gzips rotated files.
+	Compress bool `json:"compress" yaml:"compress"`
+}
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it by
+// size and time. Rotated files are renamed to name-<timestamp>.ext in the
+// same directory, then compressed and removed in the background according
+// to the RotateConfig.
+type RotatingFile struct {
+	filename string
+	cfg      RotateConfig
+	maxSize  int64
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	nextRotate time.Time // zero when time-based rotation is disabled
+	closed     bool
+
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+	now    func() time.Time
+}
+
+// NewRotatingFile opens filename for appending, creating it and its
+// directory if needed.
+func NewRotatingFile(filename string, cfg RotateConfig) (*RotatingFile, error) {
+	switch cfg.Rotation {
+	case "", "hourly", "daily":
+	default:
+		return nil, fmt.Errorf("logger: unknown rotation %q", cfg.Rotation)
+	}
+
+	r := &RotatingFile{
+		filename: filename,
+		cfg:      cfg,
+		maxSize:  int64(cfg.MaxSize) * megabyte,
+		now:      time.Now,
+	}
+	if err := r.openExisting(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes p to the current file, rotating it first if p does not fit
+// within MaxSize or the rotation period has ended. A single write larger
+// than MaxSize goes to a fresh file. Writing after Close returns
+// os.ErrClosed.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		if err := r.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+
+	now := r.now()
+	sizeExceeded := r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize
+	periodEnded := !r.nextRotate.IsZero() && !now.Before(r.nextRotate)
+	if sizeExceeded || periodEnded {
+		if err := r.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync commits the current file to stable storage.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Rotate closes the current file, renames it and opens a new one.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	return r.rotate(r.now())
+}
+
+// Close closes the current file and waits for background compression and
+// cleanup to finish. Closing twice is a no-op.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	r.closed = true
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// openExisting opens the log file for appending. The caller must hold r.mu
+// or own r exclusively.
+func (r *RotatingFile) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+	// a file left over from an earlier period is rotated on the first write
+	start := r.now()
+	if r.size > 0 {
+		start = info.ModTime()
+	}
+	r.nextRotate = r.periodEnd(start)
+	return nil
+}
+
+// rotate must be called with r.mu held.
+func (r *RotatingFile) rotate(now time.Time) error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+		r.file = nil
+	}
+
+	if r.size > 0 {
+		if err := os.Rename(r.filename, r.backupName(now)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.size = 0
+	r.nextRotate = r.periodEnd(now)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.millMu.Lock()
+		defer r.millMu.Unlock()
+		_ = r.mill()
+	}()
+	return nil
+}
+
+// periodEnd returns the start of the rotation period after t, or the zero
+// time when time-based rotation is disabled.
+func (r *RotatingFile) periodEnd(t time.Time) time.Time {
+	switch r.cfg.Rotation {
+	case "hourly":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case "daily":
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+func (r *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := r.prefixAndExt()
+	stamp := t.Format(backupTimeFormat)
+	// several rotations within one millisecond must not overwrite each other,
+	// and a counter freed by mill must not be reused, or the newest backup
+	// would sort as the oldest
+	seq := 0
+	backups, _ := r.backups()
+	for _, b := range backups {
+		if b.t.Format(backupTimeFormat) == stamp && b.seq >= seq {
+			seq = b.seq + 1
+		}
+	}
+	for ; ; seq++ {
+		name := prefix + stamp + ext
+		if seq > 0 {
+			name = fmt.Sprintf("%s%s.%d%s", prefix, stamp, seq, ext)
+		}
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err = os.Stat(name + compressSuffix); os.IsNotExist(err) {
+				return name
+			}
+		}
+	}
+}
+
+// prefixAndExt splits the file name into "dir/name-" and ".ext".
+func (r *RotatingFile) prefixAndExt() (string, string) {
+	ext := filepath.Ext(r.filename)
+	return strings.TrimSuffix(r.filename, ext) + "-", ext
+}
+
+type backupFile struct {
+	path string
+	t    time.Time
+	seq  int // counter added by backupName, 0 for the first file of t
+}
+
+// mill compresses rotated files and removes the ones exceeding MaxBackups or
+// MaxAge.
+func (r *RotatingFile) mill() error {
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	var remove []backupFile
+	if r.cfg.MaxBackups > 0 && len(backups) > r.cfg.MaxBackups {
+		remove = backups[r.cfg.MaxBackups:]
+		backups = backups[:r.cfg.MaxBackups]
+	}
+	if r.cfg.MaxAge > 0 {
+		cutoff := r.now().Add(-time.Duration(r.cfg.MaxAge) * 24 * time.Hour)
+		kept := backups[:0]
+		for _, b := range backups {
+			if b.t.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+
+	var errs []error
+	for _, b := range remove {
+		if err = os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	if r.cfg.Compress {
+		for _, b := range backups {
+			if !strings.HasSuffix(b.path, compressSuffix) {
+				if err = compressFile(b.path); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backups returns the rotated files of r, newest first.
+func (r *RotatingFile) backups() ([]backupFile, error) {
+	entries, err := os.ReadDir(filepath.Dir(r.filename))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ext := r.prefixAndExt()
+	prefix = filepath.Base(prefix)
+	var res []backupFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(e.Name(), prefix), compressSuffix)
+		ts = strings.TrimSuffix(ts, ext)
+		seq := 0
+		if len(ts) > len(backupTimeFormat) {
+			// strip the counter added by backupName
+			seq, err = strconv.Atoi(strings.TrimPrefix(ts[len(backupTimeFormat):], "."))
+			if err != nil {
+				continue
+			}
+			ts = ts[:len(backupTimeFormat)]
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+		res = append(res, backupFile{path: filepath.Join(filepath.Dir(r.filename), e.Name()), t: t, seq: seq})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].t.Equal(res[j].t) {
+			return res[i].t.After(res[j].t)
+		}
+		return res[i].seq > res[j].seq
+	})
+	return res, nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,254 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingFile(filepath.Join(dir, "logs", "app.log"), RotateConfig{MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	r.maxSize = 10
+
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	r.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffff\n", "gggg\n"} {
+		if _, err = r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err = r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	names := listDir(t, filepath.Join(dir, "logs"))
+	if len(names) != 3 || names[2] != "app.log" {
+		t.Fatalf("expected app.log and 2 backups, got %v", names)
+	}
+	// only the two newest backups are kept
+	data, _ := os.ReadFile(filepath.Join(dir, "logs", names[1]))
+	if string(data) != "eeee\nffff\n" {
+		t.Fatalf("newest backup = %q", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "logs", "app.log"))
+	if string(data) != "gggg\n" {
+		t.Fatalf("current file = %q", data)
+	}
+}
+
+func TestRotatingFileDailyCompress(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.Local)
+
+	r, err := NewRotatingFile(filename, RotateConfig{Rotation: "daily", Compress: true, MaxAge: 1})
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	r.now = func() time.Time { return now }
+	r.nextRotate = r.periodEnd(now)
+
+	// a backup older than MaxAge is removed
+	old := filepath.Join(dir, "app-2026-10-01T00-00-00.000.log.gz")
+	if err = os.WriteFile(old, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = r.Write([]byte("day one\n"))
+	now = now.Add(2 * time.Minute)
+	_, _ = r.Write([]byte("day two\n"))
+	if err = r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	names := listDir(t, dir)
+	if len(names) != 2 || names[0] != "app-2026-10-18T00-01-00.000.log.gz" || names[1] != "app.log" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	f, err := os.Open(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	data, _ := io.ReadAll(gz)
+	if string(data) != "day one\n" {
+		t.Fatalf("compressed backup = %q", data)
+	}
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	r, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), RotateConfig{})
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	if err = r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err = r.Write([]byte("late\n")); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Write after Close = %v, want %v", err, os.ErrClosed)
+	}
+	if err = r.Sync(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Sync after Close = %v, want %v", err, os.ErrClosed)
+	}
+	if err = r.Rotate(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Rotate after Close = %v, want %v", err, os.ErrClosed)
+	}
+	if err = r.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingFile(filepath.Join(dir, "app.log"), RotateConfig{MaxBackups: 3, Compress: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	r.now = func() time.Time { return now }
+
+	// 12 rotations within one millisecond, so the counter reaches two digits
+	for i := 0; i < 12; i++ {
+		_, _ = fmt.Fprintf(r, "%d\n", i)
+		if err = r.Rotate(); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+	}
+	if err = r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// the counter orders backups of the same millisecond, so the newest three are kept
+	want := []string{"app-2026-10-17T09-00-00.000.10.log.gz", "app-2026-10-17T09-00-00.000.11.log.gz",
+		"app-2026-10-17T09-00-00.000.9.log.gz", "app.log"}
+	if names := listDir(t, dir); strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	for i := 9; i < 12; i++ {
+		name := fmt.Sprintf("app-2026-10-17T09-00-00.000.%d.log.gz", i)
+		if got := readGzip(t, filepath.Join(dir, name)); got != fmt.Sprintf("%d\n", i) {
+			t.Fatalf("%s = %q", name, got)
+		}
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	r, err := NewRotatingFile(filepath.Join(dir, "app.log"), RotateConfig{MaxAge: 7})
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	r.now = func() time.Time { return now }
+
+	for _, name := range []string{
+		"app-2026-10-09T09-00-00.000.log",   // 8 days old
+		"app-2026-10-09T09-00-00.000.1.log", // 8 days old, same millisecond
+		"app-2026-10-11T09-00-00.000.log",   // 6 days old
+		"other-2026-10-01T09-00-00.000.log", // not a backup of app.log
+		"app-notes.log",                     // not a backup name
+	} {
+		if err = os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = r.Write([]byte("x\n"))
+	if err = r.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if err = r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := []string{"app-2026-10-11T09-00-00.000.log", "app-2026-10-17T09-00-00.000.log", "app-notes.log",
+		"app.log", "other-2026-10-01T09-00-00.000.log"}
+	if names := listDir(t, dir); strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	data, _ := io.ReadAll(gz)
+	return string(data)
+}
+
+func TestNewOutputs(t *testing.T) {
+	dir := t.TempDir()
+	errLevel := ErrorLevel
+	l, err := New(Config{
+		Level: DebugLevel,
+		Outputs: []OutputConfig{
+			{Path: filepath.Join(dir, "app.log")},
+			{Path: filepath.Join(dir, "error.log"), Encoding: "console", Level: &errLevel},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	l.Debug("starting")
+	l.Named("pay").Error("notify failed", String("order_id", "o-1"))
+	if err = l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	app, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if lines := strings.Split(strings.TrimSpace(string(app)), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "{") {
+		t.Fatalf("app.log = %q", app)
+	}
+	errs, _ := os.ReadFile(filepath.Join(dir, "error.log"))
+	if s := string(errs); strings.Contains(s, "starting") || !strings.Contains(s, "ERROR\tpay\t") || !strings.Contains(s, `"order_id": "o-1"`) {
+		t.Fatalf("error.log = %q", errs)
+	}
+
+	if _, err = New(Config{Outputs: []OutputConfig{{Path: filepath.Join(dir, "x.log"), Rotate: RotateConfig{Rotation: "weekly"}}}}); err == nil {
+		t.Fatal("expected error for unknown rotation")
+	}
+	if _, err = New(Config{Outputs: []OutputConfig{{}}}); err == nil {
+		t.Fatal("expected error for empty path")
+	}
+}
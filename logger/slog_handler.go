@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler that forwards records to a Logger, so that
+// libraries logging through log/slog share the sink, levels and fields of the
+// application logger.
+//
+// Records at slog.LevelError and above are logged with Logger.Error; the
+// handler never calls Fatal or Panic.
+type SlogHandler struct {
+	l      Logger
+	prefix string // open groups, joined and terminated by "."
+}
+
+// NewSlogHandler returns a slog.Handler that forwards to l.
+//
+// If l is a *SlogLogger without a redactor, levels or module, its own handler
+// is returned instead, so records are not converted twice.
+func NewSlogHandler(l Logger) slog.Handler {
+	if s, ok := l.(*SlogLogger); ok && s.redactor == nil && s.levels == nil && s.module == "" {
+		return s.l.Handler()
+	}
+	return &SlogHandler{l: l}
+}
+
+// Enabled reports whether the wrapped Logger handles level. Loggers that do
+// not expose their level are assumed to handle every level.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if e, ok := h.l.(interface {
+		Enabled(context.Context, slog.Level) bool
+	}); ok {
+		return e.Enabled(ctx, level)
+	}
+	return true
+}
+
+// Handle forwards r together with the fields of ContextFields(ctx).
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ctxFields := ContextFields(ctx)
+	fields := make([]Field, 0, len(ctxFields)+r.NumAttrs())
+	fields = append(fields, ctxFields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+
+	// write to zap directly so the entry keeps the time and caller of the
+	// slog call instead of pointing at this handler
+	if z, ok := h.l.(*ZapLogger); ok {
+		level := bridgeLevel(r.Level)
+		if !z.enabled(level) {
+			return nil
+		}
+		ce := z.l.Check(level.zapLevel(), z.redactMsg(r.Message))
+		if ce == nil {
+			return nil
+		}
+		if !r.Time.IsZero() {
+			ce.Time = r.Time
+		}
+		if ce.Caller.Defined && r.PC != 0 {
+			frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+			ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			ce.Caller.Function = frame.Function
+		}
+		ce.Write(z.toArgs(fields)...)
+		return nil
+	}
+	if s, ok := h.l.(*SlogLogger); ok {
+		return s.handle(ctx, r, fields)
+	}
+
+	switch {
+	case r.Level < slog.LevelInfo:
+		h.l.Debug(r.Message, fields...)
+	case r.Level < slog.LevelWarn:
+		h.l.Info(r.Message, fields...)
+	case r.Level < slog.LevelError:
+		h.l.Warn(r.Message, fields...)
+	default:
+		h.l.Error(r.Message, fields...)
+	}
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &SlogHandler{l: h.l.With(fields...), prefix: h.prefix}
+}
+
+// WithGroup qualifies the keys of later attributes with name, for example
+// slog.Group("http", "status", 200) is logged as the field "http.status".
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{l: h.l, prefix: h.prefix + name + "."}
+}
+
+// Sync flushes the wrapped Logger.
+func (h *SlogHandler) Sync() error {
+	return h.l.Sync()
+}
+
+// appendAttr converts a slog attribute to fields, flattening groups into
+// dotted keys.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range attrs {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, String(prefix+a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(prefix+a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, Any(prefix+a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, Float64(prefix+a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, Bool(prefix+a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, Duration(prefix+a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(prefix+a.Key, a.Value.Time()))
+	default:
+		return append(fields, Any(prefix+a.Key, a.Value.Any()))
+	}
+}
+
+// bridgeLevel maps a slog level to the closest Level. Levels above
+// slog.LevelError map to ErrorLevel so that a slog call never exits or
+// panics.
+func bridgeLevel(level slog.Level) Level {
+	return min(levelOf(level), ErrorLevel)
+}
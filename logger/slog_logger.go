@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Levels used by SlogLogger for Panic and Fatal, which log/slog does not define.
+const (
+	LevelPanic slog.Level = slog.LevelError + 4
+	LevelFatal slog.Level = slog.LevelError + 8
+)
+
+// SlogLogger implements Logger on top of a *slog.Logger.
+type SlogLogger struct {
+	l        *slog.Logger
+	levels   *Levels
+	module   string
+	redactor *Redactor
+	skip     int // extra caller frames to skip
+
+	pkg atomic.Pointer[SlogLogger] // see pkgLogger
+}
+
+func NewSlogLogger(l *slog.Logger, opts ...Option) *SlogLogger {
+	o := applyOptions(opts)
+	return &SlogLogger{
+		l:        l,
+		levels:   o.levels,
+		redactor: o.redactor,
+	}
+}
+
+func (s *SlogLogger) Debug(msg string, args ...Field) {
+	s.log(context.Background(), slog.LevelDebug, msg, args)
+}
+
+func (s *SlogLogger) Info(msg string, args ...Field) {
+	s.log(context.Background(), slog.LevelInfo, msg, args)
+}
+
+func (s *SlogLogger) Warn(msg string, args ...Field) {
+	s.log(context.Background(), slog.LevelWarn, msg, args)
+}
+
+func (s *SlogLogger) Error(msg string, args ...Field) {
+	s.log(context.Background(), slog.LevelError, msg, args)
+}
+
+// Fatal logs at LevelFatal, syncs and then calls os.Exit(1).
+func (s *SlogLogger) Fatal(msg string, args ...Field) {
+	s.log(context.Background(), LevelFatal, msg, args)
+	_ = s.Sync()
+	os.Exit(1)
+}
+
+// Panic logs at LevelPanic and then panics with msg.
+func (s *SlogLogger) Panic(msg string, args ...Field) {
+	s.log(context.Background(), LevelPanic, msg, args)
+	panic(msg)
+}
+
+// DebugContext passes ctx to the handler and adds the fields of
+// ContextFields(ctx).
+func (s *SlogLogger) DebugContext(ctx context.Context, msg string, args ...Field) {
+	s.log(ctx, slog.LevelDebug, msg, args)
+}
+
+func (s *SlogLogger) InfoContext(ctx context.Context, msg string, args ...Field) {
+	s.log(ctx, slog.LevelInfo, msg, args)
+}
+
+func (s *SlogLogger) WarnContext(ctx context.Context, msg string, args ...Field) {
+	s.log(ctx, slog.LevelWarn, msg, args)
+}
+
+func (s *SlogLogger) ErrorContext(ctx context.Context, msg string, args ...Field) {
+	s.log(ctx, slog.LevelError, msg, args)
+}
+
+func (s *SlogLogger) With(args ...Field) Logger {
+	return &SlogLogger{
+		l:        slog.New(s.l.Handler().WithAttrs(s.toAttrs(args))),
+		levels:   s.levels,
+		module:   s.module,
+		redactor: s.redactor,
+	}
+}
+
+// Named returns a sub-logger for a module. slog has no logger names, so the
+// module is logged as the "logger" attribute.
+func (s *SlogLogger) Named(name string) Logger {
+	return &SlogLogger{
+		l:        s.l,
+		levels:   s.levels,
+		module:   joinName(s.module, name),
+		redactor: s.redactor,
+	}
+}
+
+// Sync flushes the underlying handler if it supports it, for example a
+// handler created by NewSlogHandler.
+func (s *SlogLogger) Sync() error {
+	if syncer, ok := s.l.Handler().(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Enabled reports whether the logger and the underlying handler handle
+// records at level.
+func (s *SlogLogger) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.enabled(levelOf(level)) && s.l.Handler().Enabled(ctx, level)
+}
+
+// Levels returns the levels set by WithLevels, or nil.
+func (s *SlogLogger) Levels() *Levels {
+	return s.levels
+}
+
+// pkgLogger returns a copy of s that skips one more caller frame, used by the
+// package-level functions such as Info so the caller is their caller.
+func (s *SlogLogger) pkgLogger() Logger {
+	if p := s.pkg.Load(); p != nil {
+		return p
+	}
+	p := &SlogLogger{
+		l:        s.l,
+		levels:   s.levels,
+		module:   s.module,
+		redactor: s.redactor,
+		skip:     s.skip + 1,
+	}
+	s.pkg.Store(p)
+	return p
+}
+
+func (s *SlogLogger) enabled(level Level) bool {
+	return s.levels == nil || s.levels.Enabled(s.module, level)
+}
+
+// Slog returns the underlying *slog.Logger.
+func (s *SlogLogger) Slog() *slog.Logger {
+	return s.l
+}
+
+func (s *SlogLogger) log(ctx context.Context, level slog.Level, msg string, args []Field) {
+	h := s.l.Handler()
+	if !s.enabled(levelOf(level)) || !h.Enabled(ctx, level) {
+		return
+	}
+	// a SlogHandler adds the context fields itself
+	if _, ok := h.(*SlogHandler); !ok {
+		args = withContext(ctx, args)
+	}
+	if s.redactor != nil {
+		msg = s.redactor.RedactString(msg)
+	}
+
+	// skip runtime.Callers, log and the exported method so that the
+	// record points at the caller of the Logger method
+	var pcs [1]uintptr
+	runtime.Callers(3+s.skip, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if s.module != "" {
+		r.AddAttrs(slog.String("logger", s.module))
+	}
+	r.AddAttrs(s.toAttrs(args)...)
+	_ = h.Handle(ctx, r)
+}
+
+// handle logs a record forwarded by a SlogHandler, keeping its time and
+// caller. fields already include the context fields.
+func (s *SlogLogger) handle(ctx context.Context, r slog.Record, fields []Field) error {
+	h := s.l.Handler()
+	if !s.enabled(levelOf(r.Level)) || !h.Enabled(ctx, r.Level) {
+		return nil
+	}
+	msg := r.Message
+	if s.redactor != nil {
+		msg = s.redactor.RedactString(msg)
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, msg, r.PC)
+	if s.module != "" {
+		nr.AddAttrs(slog.String("logger", s.module))
+	}
+	nr.AddAttrs(s.toAttrs(fields)...)
+	return h.Handle(ctx, nr)
+}
+
+func (s *SlogLogger) toAttrs(args []Field) []slog.Attr {
+	if len(args) == 0 {
+		return nil
+	}
+	if s.redactor != nil {
+		args = s.redactor.Redact(args)
+	}
+
+	res := make([]slog.Attr, 0, len(args))
+	for _, arg := range args {
+		res = append(res, slog.Any(arg.Key, arg.Value))
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})))
+
+	l.With(String("service", "api")).Info("request done",
+		Int("status", 200), Err(errors.New("boom")), Strs("tags", []string{"a", "b"}))
+	l.Debug("hidden")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "request done" || entry["level"] != "INFO" || entry["service"] != "api" ||
+		entry["status"] != float64(200) || entry["error"] != "boom" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+	source, _ := entry["source"].(map[string]any)
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "slog_test.go") {
+		t.Fatalf("expected source in slog_test.go, got %v", entry["source"])
+	}
+}
+
+func TestSlogLoggerPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+		if !strings.Contains(buf.String(), "level=ERROR+4") {
+			t.Fatalf("expected LevelPanic record, got %q", buf.String())
+		}
+	}()
+	l.Panic("bad state")
+}
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := NewZapLogger(zap.New(core, zap.AddCaller()))
+	sl := slog.New(NewSlogHandler(base)).With("component", "db")
+
+	sl.Debug("hidden")
+	sl.WithGroup("query").Info("slow query",
+		"ms", 120, slog.Group("table", "name", "users"), "err", errors.New("timeout"))
+	sl.Error("failed")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	got := entries[0]
+	if got.Message != "slow query" || got.Level != zapcore.InfoLevel {
+		t.Fatalf("unexpected entry: %+v", got.Entry)
+	}
+	fields := got.ContextMap()
+	want := map[string]any{
+		"component":        "db",
+		"query.ms":         int64(120),
+		"query.table.name": "users",
+		"query.err":        "timeout",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Fatalf("field %s = %v, want %v (all: %v)", k, fields[k], v, fields)
+		}
+	}
+	if !strings.HasSuffix(got.Caller.File, "slog_test.go") {
+		t.Fatalf("expected caller in slog_test.go, got %s", got.Caller.File)
+	}
+	if entries[1].Level != zapcore.ErrorLevel {
+		t.Fatalf("expected error level, got %v", entries[1].Level)
+	}
+}
+
+func TestSlogHandlerUnwrap(t *testing.T) {
+	h := slog.NewJSONHandler(&bytes.Buffer{}, nil)
+	if got := NewSlogHandler(NewSlogLogger(slog.New(h))); got != slog.Handler(h) {
+		t.Fatalf("expected the underlying handler, got %T", got)
+	}
+}
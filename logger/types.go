@@ -1,5 +1,7 @@
 package logger
 
+import "context"
+
 type Field struct {
 	Key   string
 	Value any
@@ -12,6 +14,11 @@ type Logger interface {
 	Error(msg string, args ...Field)
 	Fatal(msg string, args ...Field)
 	Panic(msg string, args ...Field)
+	DebugContext(ctx context.Context, msg string, args ...Field)
+	InfoContext(ctx context.Context, msg string, args ...Field)
+	WarnContext(ctx context.Context, msg string, args ...Field)
+	ErrorContext(ctx context.Context, msg string, args ...Field)
 	With(args ...Field) Logger
+	Named(name string) Logger
 	Sync() error
 }
@@ -0,0 +1,239 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Outcome 一次操作的结果，AdaptiveLimiter 根据它调整并发上限
+type Outcome int
+
+const (
+	// OutcomeSuccess 操作成功，延迟会作为调整上限的样本
+	OutcomeSuccess Outcome = iota
+	// OutcomeDropped 操作因为过载失败，例如超时或者下游返回 503，上限会下调
+	OutcomeDropped
+	// OutcomeIgnore 不参与调整，例如参数错误这类和负载无关的失败
+	OutcomeIgnore
+)
+
+// AdaptiveAlgorithm 根据一次操作的结果计算新的并发上限
+//
+// AdaptiveLimiter 在持有锁的情况下调用 Update，实现不需要自己处理并发。
+type AdaptiveAlgorithm interface {
+	Update(limit, inflight int, rtt time.Duration, dropped bool) int
+}
+
+// AIMD 加性增、乘性减：成功时上限加一，过载时上限乘以 BackoffRatio
+type AIMD struct {
+	// MinLimit 默认 1
+	MinLimit int
+	// MaxLimit 默认 1000
+	MaxLimit int
+	// BackoffRatio 默认 0.9
+	BackoffRatio float64
+	// Timeout 延迟超过它的成功请求也按过载处理，0 表示不根据延迟判断
+	Timeout time.Duration
+}
+
+func (a *AIMD) Update(limit, inflight int, rtt time.Duration, dropped bool) int {
+	if dropped || (a.Timeout > 0 && rtt > a.Timeout) {
+		ratio := a.BackoffRatio
+		if ratio <= 0 || ratio >= 1 {
+			ratio = 0.9
+		}
+		limit = int(float64(limit) * ratio)
+	} else if inflight*2 >= limit {
+		// 只有并发确实用到一半以上时才上调，避免空闲时上限无限增长
+		limit++
+	}
+	return clampLimit(limit, a.MinLimit, a.MaxLimit)
+}
+
+// Gradient 根据短期延迟和长期平均延迟的比值调整上限，思路来自 Netflix concurrency-limits 的 Gradient2：
+// 延迟升高说明请求开始排队，按比例收缩上限；延迟稳定时额外放出 sqrt(limit) 的排队空间去探测更高的上限。
+type Gradient struct {
+	// MinLimit 默认 1
+	MinLimit int
+	// MaxLimit 默认 1000
+	MaxLimit int
+	// Tolerance 允许短期延迟比长期平均延迟高多少倍而不收缩上限，默认 1.5
+	Tolerance float64
+	// Smoothing 新上限的平滑系数，越小变化越平缓，默认 0.2
+	Smoothing float64
+	// LongWindow 长期平均延迟的样本窗口，默认 600
+	LongWindow int
+
+	longRtt float64
+	samples int
+}
+
+func (g *Gradient) Update(limit, inflight int, rtt time.Duration, dropped bool) int {
+	tolerance := defaultFloat(g.Tolerance, 1.5)
+	smoothing := defaultFloat(g.Smoothing, 0.2)
+	window := g.LongWindow
+	if window <= 0 {
+		window = 600
+	}
+
+	if dropped {
+		return clampLimit(int(float64(limit)*(1-smoothing/2)), g.MinLimit, g.MaxLimit)
+	}
+
+	short := float64(rtt)
+	if short <= 0 {
+		return limit
+	}
+	// 样本不足时使用简单平均，之后使用指数移动平均
+	g.samples++
+	if g.samples <= window {
+		g.longRtt += (short - g.longRtt) / float64(g.samples)
+	} else {
+		g.longRtt += (short - g.longRtt) * 2 / float64(window+1)
+	}
+	// 长期延迟明显偏高时加快回落，避免负载下降后上限长时间恢复不了
+	if g.longRtt/short > 2 {
+		g.longRtt *= 0.95
+	}
+
+	// 并发没有用到一半时，延迟样本说明不了上限是否合适
+	if inflight*2 < limit {
+		return limit
+	}
+
+	gradient := math.Max(0.5, math.Min(1, tolerance*g.longRtt/short))
+	next := float64(limit)*gradient + math.Sqrt(float64(limit))
+	next = float64(limit)*(1-smoothing) + next*smoothing
+	return clampLimit(int(math.Round(next)), g.MinLimit, g.MaxLimit)
+}
+
+func defaultFloat(v, def float64) float64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func clampLimit(limit, minLimit, maxLimit int) int {
+	if minLimit <= 0 {
+		minLimit = 1
+	}
+	if maxLimit <= 0 {
+		maxLimit = 1000
+	}
+	return min(max(limit, minLimit), maxLimit)
+}
+
+// AdaptiveLimiter 根据调用方上报的延迟和错误自动调整并发上限的并发限流器
+//
+// 所有 key 共享同一个上限，key 只记录在租约中，需要按 key 区分时为每个 key 创建一个。
+// 操作结束后通过 Release（视为成功）或者 ReleaseWithOutcome 上报结果，
+// 超过 leaseTTL 没有归还的租约按过载处理。
+type AdaptiveLimiter struct {
+	mu        sync.Mutex
+	algorithm AdaptiveAlgorithm
+	limit     int
+	leases    map[string]time.Time
+	leaseTTL  time.Duration
+	now       func() time.Time
+}
+
+// NewAdaptiveLimiter 创建自适应并发限流器，initialLimit 是初始的并发上限
+func NewAdaptiveLimiter(algorithm AdaptiveAlgorithm, initialLimit int, leaseTTL time.Duration) *AdaptiveLimiter {
+	return &AdaptiveLimiter{
+		algorithm: algorithm,
+		limit:     max(initialLimit, 1),
+		leases:    make(map[string]time.Time),
+		leaseTTL:  leaseTTL,
+		now:       time.Now,
+	}
+}
+
+// CurrentLimit 返回当前的并发上限
+func (a *AdaptiveLimiter) CurrentLimit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// Inflight 返回当前进行中的操作数量
+func (a *AdaptiveLimiter) Inflight() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.leases)
+}
+
+func (a *AdaptiveLimiter) TryAcquire(ctx context.Context, key string) (Lease, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Lease{}, false, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.expire(now)
+	if len(a.leases) >= a.limit {
+		return Lease{}, false, nil
+	}
+	lease := Lease{Key: key, ID: newLeaseID(), AcquiredAt: now, ExpireAt: now.Add(a.leaseTTL)}
+	a.leases[lease.ID] = lease.ExpireAt
+	return lease, true, nil
+}
+
+func (a *AdaptiveLimiter) Acquire(ctx context.Context, key string) (Lease, error) {
+	return waitAcquire(ctx, key, a.TryAcquire)
+}
+
+// Release 归还名额，并把这次操作当作成功上报
+func (a *AdaptiveLimiter) Release(ctx context.Context, lease Lease) error {
+	return a.ReleaseWithOutcome(ctx, lease, OutcomeSuccess)
+}
+
+// ReleaseWithOutcome 归还名额并上报操作结果，延迟从 lease.AcquiredAt 开始计算
+func (a *AdaptiveLimiter) ReleaseWithOutcome(ctx context.Context, lease Lease, outcome Outcome) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.leases[lease.ID]; !ok {
+		// 租约已经过期，过期时已经按过载上报过了
+		return nil
+	}
+	inflight := len(a.leases)
+	delete(a.leases, lease.ID)
+	if outcome != OutcomeIgnore {
+		a.update(inflight, a.now().Sub(lease.AcquiredAt), outcome == OutcomeDropped)
+	}
+	return nil
+}
+
+func (a *AdaptiveLimiter) Refresh(ctx context.Context, lease Lease) (Lease, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.expire(now)
+	if _, ok := a.leases[lease.ID]; !ok {
+		return Lease{}, ErrLeaseNotFound
+	}
+	lease.ExpireAt = now.Add(a.leaseTTL)
+	a.leases[lease.ID] = lease.ExpireAt
+	return lease, nil
+}
+
+// expire 清理过期的租约并按过载上报，调用方需要持有 a.mu
+func (a *AdaptiveLimiter) expire(now time.Time) {
+	for id, expireAt := range a.leases {
+		if !expireAt.After(now) {
+			inflight := len(a.leases)
+			delete(a.leases, id)
+			a.update(inflight, a.leaseTTL, true)
+		}
+	}
+}
+
+func (a *AdaptiveLimiter) update(inflight int, rtt time.Duration, dropped bool) {
+	a.limit = max(a.algorithm.Update(a.limit, inflight, rtt, dropped), 1)
+}
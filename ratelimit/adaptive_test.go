@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAIMD(t *testing.T) {
+	a := &AIMD{MinLimit: 2, MaxLimit: 12, Timeout: time.Second}
+
+	if got := a.Update(10, 6, 10*time.Millisecond, false); got != 11 {
+		t.Fatalf("busy success = %d, want 11", got)
+	}
+	if got := a.Update(10, 1, 10*time.Millisecond, false); got != 10 {
+		t.Fatalf("idle success = %d, want unchanged", got)
+	}
+	if got := a.Update(10, 6, 0, true); got != 9 {
+		t.Fatalf("dropped = %d, want 9", got)
+	}
+	if got := a.Update(10, 6, 2*time.Second, false); got != 9 {
+		t.Fatalf("slow success = %d, want 9", got)
+	}
+	if got := a.Update(12, 12, 0, false); got != 12 {
+		t.Fatalf("limit = %d, want clamped to max", got)
+	}
+	if got := a.Update(2, 2, 0, true); got != 2 {
+		t.Fatalf("limit = %d, want clamped to min", got)
+	}
+}
+
+func TestGradientShrinksWhenLatencyGrows(t *testing.T) {
+	g := &Gradient{MaxLimit: 100}
+	limit := 20
+	for i := 0; i < 50; i++ {
+		limit = g.Update(limit, limit, 10*time.Millisecond, false)
+	}
+	grown := limit
+	if grown <= 20 {
+		t.Fatalf("limit = %d, want growth with stable latency", grown)
+	}
+
+	for i := 0; i < 10; i++ {
+		limit = g.Update(limit, limit, 100*time.Millisecond, false)
+	}
+	if limit >= grown {
+		t.Fatalf("limit = %d, want below %d after latency spike", limit, grown)
+	}
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := NewAdaptiveLimiter(&AIMD{}, 2, time.Minute)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	first, ok, _ := l.TryAcquire(ctx, "k")
+	if !ok {
+		t.Fatal("first acquire should succeed")
+	}
+	second, ok, _ := l.TryAcquire(ctx, "k")
+	if !ok {
+		t.Fatal("second acquire should succeed")
+	}
+	if _, ok, _ = l.TryAcquire(ctx, "k"); ok {
+		t.Fatal("third acquire should fail")
+	}
+
+	_ = l.Release(ctx, first)
+	if got := l.CurrentLimit(); got != 3 {
+		t.Fatalf("limit = %d, want 3 after busy success", got)
+	}
+	_ = l.ReleaseWithOutcome(ctx, second, OutcomeIgnore)
+	if got := l.CurrentLimit(); got != 3 || l.Inflight() != 0 {
+		t.Fatalf("limit = %d, inflight = %d", got, l.Inflight())
+	}
+
+	// 没有归还的租约过期后按过载处理
+	_, _, _ = l.TryAcquire(ctx, "k")
+	clock.Advance(time.Minute)
+	_, _, _ = l.TryAcquire(ctx, "k")
+	if got := l.CurrentLimit(); got != 2 {
+		t.Fatalf("limit = %d, want 2 after expired lease", got)
+	}
+}
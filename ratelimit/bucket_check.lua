@@ -0,0 +1,37 @@
+-- 令牌桶算法，token_bucket.lua 和 composite.lua 共用。
+-- 用一个 hash 保存当前令牌数和上次刷新时间，内存占用与速率无关。
+
+-- 检查本次请求能否放行，返回判断结果和扣减令牌的函数，被限流时扣减函数为 nil。
+-- 桶容量 capacity 也就是允许的最大突发，每 interval 毫秒补充 rate 个令牌。
+-- 判断结果依次是：是否放行, 剩余令牌, 多久后桶会被补满, 多久后可以重试（单位都是毫秒）
+local function check_bucket(key, now, capacity, interval, rate, cost)
+    local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+    local tokens = tonumber(bucket[1])
+    local ts = tonumber(bucket[2])
+    if tokens == nil or ts == nil then
+        -- 第一次访问，桶是满的
+        tokens = capacity
+        ts = now
+    end
+
+    -- 不同实例之间可能有时钟误差，时间倒退时不补充令牌
+    if now > ts then
+        tokens = math.min(capacity, tokens + (now - ts) * rate / interval)
+        ts = now
+    end
+
+    -- 桶从空到满需要的时间，过了这么久没有访问，key 就可以删掉了
+    local ttl = math.ceil(capacity * interval / rate)
+
+    if tokens < cost then
+        -- 没有写回补充后的令牌数，下次访问时会从上次的时间点重新计算，结果相同
+        return {0, math.floor(tokens), math.ceil((capacity - tokens) * interval / rate),
+                math.ceil((cost - tokens) * interval / rate)}, nil
+    end
+
+    local left = tokens - cost
+    return {1, math.floor(left), math.ceil((capacity - left) * interval / rate), 0}, function()
+        redis.call('HSET', key, 'tokens', left, 'ts', ts)
+        redis.call('PEXPIRE', key, ttl)
+    end
+end
@@ -0,0 +1,257 @@
+package ratelimit
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed composite.lua
+var luaComposite string
+
+var compositeScript = redis.NewScript(luaWindowCheck + luaBucketCheck + luaComposite)
+
+// Rule 组合限流中的一条规则
+type Rule struct {
+	// Name 规则名称，只用于错误信息
+	Name string
+	// Limiter 只支持本包提供的 Redis 和进程内限流器
+	Limiter Limiter
+	// Key 根据调用方传入的 key 生成这条规则实际使用的 key，为空时直接使用传入的 key。
+	// 例如按租户限流时从 ctx 中取出租户 ID，全局限流时返回固定的 key。
+	Key func(ctx context.Context, key string) string
+}
+
+// compositeRule 是 Redis 限流器在组合脚本中的参数
+type compositeRule struct {
+	kind  string
+	keys  []string
+	args  []any
+	limit int
+}
+
+// redisRule 可以合并到同一个 Lua 脚本中执行的 Redis 限流器
+type redisRule interface {
+	redisClient() redis.Cmdable
+	hashTag() string
+	compositeRule(key string, now time.Time, n int) compositeRule
+}
+
+// memoryRule 可以和其他规则一起检查、一起扣减的进程内限流器
+type memoryRule interface {
+	lockID() uint64
+	lock()
+	unlock()
+	reserve(key string, now time.Time, n int) (Decision, func(), error)
+}
+
+// CompositeLimiter 同时检查多条限流规则，只有所有规则都放行时才扣减配额
+//
+// 所有 Redis 规则在同一个 Lua 脚本中完成检查和扣减，只需要一次网络往返，
+// 因此它们必须使用同一个 Redis 客户端；使用 *redis.ClusterClient 时，
+// 还必须通过 WithHashTag 给它们设置相同的 hash tag。进程内规则在检查期间会一直持有各自的锁，
+// 直到 Redis 返回结果，保证不会出现部分规则扣减、部分规则拒绝的情况。
+type CompositeLimiter struct {
+	rules  []Rule
+	cmd    redis.Cmdable
+	memory []memoryRule
+	now    func() time.Time
+}
+
+// NewCompositeLimiter 创建组合限流器，例如同时限制每个用户 10 次/秒、每个租户 1000 次/分钟
+func NewCompositeLimiter(rules ...Rule) (*CompositeLimiter, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w: no rules", ErrInvalidRule)
+	}
+
+	c := &CompositeLimiter{rules: rules, now: time.Now}
+	var tag string
+	for i, rule := range rules {
+		switch l := rule.Limiter.(type) {
+		case redisRule:
+			if c.cmd == nil {
+				c.cmd, tag = l.redisClient(), l.hashTag()
+			} else if c.cmd != l.redisClient() {
+				return nil, fmt.Errorf("%w: rule %s uses a different redis client", ErrInvalidRule, ruleName(rule, i))
+			}
+			if isCluster(c.cmd) && (l.hashTag() == "" || l.hashTag() != tag) {
+				return nil, fmt.Errorf("%w: rule %s must share a hash tag with other rules on redis cluster", ErrInvalidRule, ruleName(rule, i))
+			}
+		case memoryRule:
+			if !slices.Contains(c.memory, l) {
+				c.memory = append(c.memory, l)
+			}
+		default:
+			return nil, fmt.Errorf("%w: rule %s has unsupported limiter %T", ErrInvalidRule, ruleName(rule, i), rule.Limiter)
+		}
+	}
+	// 按固定顺序加锁，多个组合限流器共用同一个进程内限流器时也不会死锁
+	slices.SortFunc(c.memory, func(a, b memoryRule) int {
+		return cmp.Compare(a.lockID(), b.lockID())
+	})
+	return c, nil
+}
+
+func ruleName(rule Rule, i int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+func (c *CompositeLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	return c.LimitN(ctx, key, 1)
+}
+
+// LimitN 在每条规则上都消耗 n 个单位的配额，任意一条规则拒绝时都不扣减
+func (c *CompositeLimiter) LimitN(ctx context.Context, key string, n int) (bool, error) {
+	d, err := c.DecideN(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+	return !d.Allowed, nil
+}
+
+// Decide 返回合并后的判断结果，剩余配额取最紧张的那条规则
+func (c *CompositeLimiter) Decide(ctx context.Context, key string) (Decision, error) {
+	return c.DecideN(ctx, key, 1)
+}
+
+// DecideN 和 Decide 一样，只是一次消耗 n 个单位的配额
+func (c *CompositeLimiter) DecideN(ctx context.Context, key string, n int) (Decision, error) {
+	decisions, err := c.DecideRules(ctx, key, n)
+	if err != nil {
+		return Decision{}, err
+	}
+	return mergeDecisions(decisions), nil
+}
+
+// DecideRules 返回每条规则各自的判断结果，顺序与创建时传入的规则一致。
+// 被拒绝时，放行的那些规则返回的是假设扣减之后的剩余配额，实际并没有扣减。
+func (c *CompositeLimiter) DecideRules(ctx context.Context, key string, n int) ([]Decision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: cost %d", ErrInvalidCost, n)
+	}
+
+	for _, m := range c.memory {
+		m.lock()
+	}
+	defer func() {
+		for _, m := range c.memory {
+			m.unlock()
+		}
+	}()
+
+	now := c.now()
+	decisions := make([]Decision, len(c.rules))
+	commits := make([]func(), 0, len(c.memory))
+	allowed := true
+	var redisIdx []int
+	var specs []compositeRule
+	for i, rule := range c.rules {
+		ruleKey := key
+		if rule.Key != nil {
+			ruleKey = rule.Key(ctx, key)
+		}
+		switch l := rule.Limiter.(type) {
+		case redisRule:
+			spec := l.compositeRule(ruleKey, now, n)
+			if n > spec.limit {
+				return nil, fmt.Errorf("%w: rule %s cost %d, limit %d", ErrInvalidCost, ruleName(rule, i), n, spec.limit)
+			}
+			redisIdx = append(redisIdx, i)
+			specs = append(specs, spec)
+		case memoryRule:
+			d, commit, err := l.reserve(ruleKey, now, n)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", ruleName(rule, i), err)
+			}
+			decisions[i] = d
+			if commit == nil {
+				allowed = false
+			} else {
+				commits = append(commits, commit)
+			}
+		}
+	}
+
+	if len(specs) > 0 {
+		redisDecisions, err := c.evalRedis(ctx, now, specs, allowed)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range redisIdx {
+			decisions[i] = redisDecisions[j]
+			allowed = allowed && redisDecisions[j].Allowed
+		}
+	}
+
+	if allowed {
+		for _, commit := range commits {
+			commit()
+		}
+	}
+	return decisions, nil
+}
+
+// evalRedis 在一个脚本中检查所有 Redis 规则，canCommit 为 true 且全部放行时扣减配额
+func (c *CompositeLimiter) evalRedis(ctx context.Context, now time.Time, specs []compositeRule, canCommit bool) ([]Decision, error) {
+	commitFlag := "0"
+	if canCommit {
+		commitFlag = "1"
+	}
+	keys := make([]string, 0, len(specs)*2)
+	args := make([]any, 0, 3+len(specs)*5)
+	args = append(args, now.UnixMilli(), len(specs), commitFlag)
+	for _, spec := range specs {
+		keys = append(keys, spec.keys...)
+		args = append(args, spec.kind)
+		args = append(args, spec.args...)
+	}
+
+	vals, err := compositeScript.Run(ctx, c.cmd, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) != 1+len(specs)*4 {
+		return nil, fmt.Errorf("ratelimit: unexpected composite script result length %d", len(vals))
+	}
+
+	decisions := make([]Decision, len(specs))
+	for j, spec := range specs {
+		if decisions[j], err = newDecision(now, spec.limit, vals[1+j*4:5+j*4]); err != nil {
+			return nil, err
+		}
+	}
+	return decisions, nil
+}
+
+// mergeDecisions 合并多条规则的结果：全部放行才放行，剩余配额取最少的规则，
+// 恢复时间和重试时间取最晚的规则
+func mergeDecisions(decisions []Decision) Decision {
+	merged := Decision{Allowed: true}
+	for i, d := range decisions {
+		if !d.Allowed {
+			merged.Allowed = false
+		}
+		if i == 0 || d.Remaining < merged.Remaining {
+			merged.Limit = d.Limit
+			merged.Remaining = d.Remaining
+		}
+		if d.ResetAt.After(merged.ResetAt) {
+			merged.ResetAt = d.ResetAt
+		}
+		if d.RetryAfter > merged.RetryAfter {
+			merged.RetryAfter = d.RetryAfter
+		}
+	}
+	return merged
+}
@@ -0,0 +1,43 @@
+-- 组合限流：先检查所有规则，全部通过后才真正扣减配额，
+-- 任何一条规则被限流时，其他规则的配额都不会被消耗。
+-- 规则的算法在 window_check.lua、bucket_check.lua 中，运行前会拼接到本脚本前面。
+
+local now = tonumber(ARGV[1])
+-- 规则数量
+local count = tonumber(ARGV[2])
+-- 为 "1" 时才允许扣减配额，调用方的其他规则已经拒绝时只做检查
+local can_commit = ARGV[3] == "1"
+
+-- 返回值：是否全部放行, 然后每条规则依次是 是否放行, 剩余配额, 恢复毫秒数, 重试毫秒数
+local result = {1}
+local commits = {}
+local k = 1
+for i = 0, count - 1 do
+    local base = 4 + i * 5
+    local kind = ARGV[base]
+    local decision, commit
+    if kind == 'sw' then
+        decision, commit = check_window(KEYS[k], KEYS[k + 1], now, tonumber(ARGV[base + 1]),
+                tonumber(ARGV[base + 2]), tonumber(ARGV[base + 3]), ARGV[base + 4])
+        k = k + 2
+    else
+        decision, commit = check_bucket(KEYS[k], now, tonumber(ARGV[base + 1]), tonumber(ARGV[base + 2]),
+                tonumber(ARGV[base + 3]), tonumber(ARGV[base + 4]))
+        k = k + 1
+    end
+    if commit == nil then
+        result[1] = 0
+    else
+        table.insert(commits, commit)
+    end
+    for _, v in ipairs(decision) do
+        table.insert(result, v)
+    end
+end
+
+if result[1] == 1 and can_commit then
+    for _, commit in ipairs(commits) do
+        commit()
+    end
+end
+return result
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+
+func TestCompositeLimiterCommitsOnlyWhenAllRulesPass(t *testing.T) {
+	clock := newFakeClock()
+	perUser := mustLimiter(NewMemorySlidingWindowLimiter(time.Second, 2))
+	perTenant := mustLimiter(NewMemoryFixedWindowLimiter(time.Minute, 3))
+	c, err := NewCompositeLimiter(
+		Rule{Name: "user", Limiter: perUser},
+		Rule{Name: "tenant", Limiter: perTenant, Key: func(ctx context.Context, key string) string {
+			return ctx.Value(tenantKey{}).(string)
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = clock.Now
+	ctx := context.WithValue(context.Background(), tenantKey{}, "tenant-1")
+
+	// user-1 用完自己的配额
+	for i := 0; i < 2; i++ {
+		if limited, err := c.Limit(ctx, "user-1"); limited || err != nil {
+			t.Fatalf("request %d: %v, %v", i, limited, err)
+		}
+	}
+	d, _ := c.Decide(ctx, "user-1")
+	if d.Allowed {
+		t.Fatal("user rule should reject")
+	}
+
+	// 被 user 规则拒绝的请求不能消耗租户配额
+	if limited, _ := c.Limit(ctx, "user-2"); limited {
+		t.Fatal("tenant should still have quota left")
+	}
+	decisions, _ := c.DecideRules(ctx, "user-3", 1)
+	if decisions[0].Allowed != true || decisions[1].Allowed != false {
+		t.Fatalf("decisions = %+v, want only tenant rule to reject", decisions)
+	}
+
+	// 被 tenant 规则拒绝的请求也不能消耗 user-3 的配额
+	clock.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		if limited, _ := c.Limit(ctx, "user-3"); limited {
+			t.Fatalf("user-3 request %d should be allowed", i)
+		}
+	}
+}
+
+func TestNewCompositeLimiterValidatesRules(t *testing.T) {
+	if _, err := NewCompositeLimiter(); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidRule)
+	}
+	if _, err := NewCompositeLimiter(Rule{Limiter: errLimiter{}}); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidRule)
+	}
+}
+
+func TestCompositeLimiterRedisRules(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	perTenant, err := NewRedisTokenBucketLimiter(rdb, 3, time.Minute, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perUser := NewRedisSlidingWindowLimiter(rdb, time.Second, 2).(RedisSlidingWindowLimiter)
+	c, err := NewCompositeLimiter(
+		Rule{Name: "user", Limiter: perUser},
+		Rule{Name: "tenant", Limiter: perTenant, Key: func(ctx context.Context, key string) string {
+			return ctx.Value(tenantKey{}).(string)
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = clock.Now
+	ctx := context.WithValue(context.Background(), tenantKey{}, "tenant-1")
+
+	for i := 0; i < 2; i++ {
+		if limited, err := c.Limit(ctx, "user-1"); limited || err != nil {
+			t.Fatalf("request %d: %v, %v", i, limited, err)
+		}
+	}
+	decisions, err := c.DecideRules(ctx, "user-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decisions[0].Allowed || decisions[0].Remaining != 0 || !decisions[1].Allowed || decisions[1].Remaining != 0 {
+		t.Fatalf("decisions = %+v, want only the user rule to reject", decisions)
+	}
+
+	// 被 user 规则拒绝的请求不能消耗租户令牌
+	if limited, _ := c.Limit(ctx, "user-2"); limited {
+		t.Fatal("tenant should still have a token left")
+	}
+
+	// 被 tenant 规则拒绝的请求也不能写入 user-3 的窗口
+	decisions, _ = c.DecideRules(ctx, "user-3", 1)
+	if !decisions[0].Allowed || decisions[1].Allowed || decisions[1].RetryAfter != 20*time.Second {
+		t.Fatalf("decisions = %+v, want only the tenant rule to reject", decisions)
+	}
+	if n := rdb.ZCard(ctx, perUser.windowKeys("user-3")[0]).Val(); n != 0 {
+		t.Fatalf("user-3 window has %d requests, want 0", n)
+	}
+
+	clock.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		if limited, _ := c.Limit(ctx, "user-3"); limited {
+			t.Fatalf("user-3 request %d should be allowed", i)
+		}
+	}
+}
+
+func TestCompositeLimiterMixedRules(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	bucket, err := NewRedisTokenBucketLimiter(rdb, 5, time.Second, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := mustLimiter(NewMemoryFixedWindowLimiter(time.Minute, 2))
+	c, err := NewCompositeLimiter(Rule{Name: "local", Limiter: local}, Rule{Name: "redis", Limiter: bucket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = clock.Now
+	ctx := context.Background()
+
+	if limited, _ := c.LimitN(ctx, "k", 2); limited {
+		t.Fatal("first request should be allowed")
+	}
+	// 进程内规则拒绝时，Redis 规则只做检查，不扣减令牌
+	for i := 0; i < 3; i++ {
+		if limited, err := c.LimitN(ctx, "k", 2); !limited || err != nil {
+			t.Fatalf("request %d: %v, %v; want rejected by the local rule", i, limited, err)
+		}
+	}
+	decisions, err := c.DecideRules(ctx, "k", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decisions[0].Allowed || !decisions[1].Allowed || decisions[1].Remaining != 1 {
+		t.Fatalf("decisions = %+v, want 3 tokens still available in redis", decisions)
+	}
+}
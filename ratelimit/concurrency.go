@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// acquirePollInterval Acquire 等待空闲名额时的轮询间隔
+const acquirePollInterval = 20 * time.Millisecond
+
+// Lease 并发限流器发放的租约，持有期间占用一个名额
+type Lease struct {
+	Key        string
+	ID         string
+	AcquiredAt time.Time
+	// ExpireAt 之后租约自动失效，持有者崩溃时名额不会一直被占用
+	ExpireAt time.Time
+}
+
+// ConcurrencyLimiter 限制同一个 key 同时进行中的操作数量
+type ConcurrencyLimiter interface {
+	// TryAcquire 尝试占用一个名额，没有空闲名额时立即返回 false
+	TryAcquire(ctx context.Context, key string) (Lease, bool, error)
+	// Acquire 等待直到拿到名额或者 ctx 结束
+	Acquire(ctx context.Context, key string) (Lease, error)
+	// Release 归还名额，租约已经过期或者已经归还时不会返回错误
+	Release(ctx context.Context, lease Lease) error
+	// Refresh 延长租约的有效期，长时间运行的操作需要在租约过期前调用；
+	// 租约已经失效时返回 ErrLeaseNotFound
+	Refresh(ctx context.Context, lease Lease) (Lease, error)
+}
+
+// waitAcquire 轮询 try 直到拿到名额或者 ctx 结束
+func waitAcquire(ctx context.Context, key string,
+	try func(ctx context.Context, key string) (Lease, bool, error)) (Lease, error) {
+	ticker := time.NewTicker(acquirePollInterval)
+	defer ticker.Stop()
+	for {
+		lease, ok, err := try(ctx, key)
+		if err != nil {
+			return Lease{}, err
+		}
+		if ok {
+			return lease, nil
+		}
+		select {
+		case <-ctx.Done():
+			return Lease{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func newLeaseID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,19 @@
+-- 并发限流：ZSET 里每个成员是一个租约，score 是租约的过期时间
+
+local key = KEYS[1]
+-- 最大并发数
+local limit = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+-- 租约有效期
+local ttl = tonumber(ARGV[3])
+local id = ARGV[4]
+
+-- 清理已经过期的租约，持有者崩溃时名额会在这里被回收
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+if redis.call('ZCARD', key) >= limit then
+    return 0
+end
+
+redis.call('ZADD', key, now + ttl, id)
+redis.call('PEXPIRE', key, ttl)
+return 1
@@ -0,0 +1,21 @@
+-- 延长租约，租约已经过期或者被释放时返回 0
+
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local id = ARGV[3]
+
+local expire = redis.call('ZSCORE', key, id)
+if not expire then
+    return 0
+end
+if tonumber(expire) <= now then
+    redis.call('ZREM', key, id)
+    return 0
+end
+
+redis.call('ZADD', key, now + ttl, id)
+if redis.call('PTTL', key) < ttl then
+    redis.call('PEXPIRE', key, ttl)
+end
+return 1
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mustConcurrency 返回 l，构造限流器失败时直接 panic
+func mustConcurrency(l ConcurrencyLimiter, err error) ConcurrencyLimiter {
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func TestMemoryConcurrencyLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := mustConcurrency(NewMemoryConcurrencyLimiter(2, time.Minute)).(*MemoryConcurrencyLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	first, ok, err := l.TryAcquire(ctx, "report")
+	if !ok || err != nil {
+		t.Fatalf("first acquire: %v, %v", ok, err)
+	}
+	if _, ok, _ = l.TryAcquire(ctx, "report"); !ok {
+		t.Fatal("second acquire should succeed")
+	}
+	if _, ok, _ = l.TryAcquire(ctx, "report"); ok {
+		t.Fatal("third acquire should fail")
+	}
+
+	if err = l.Release(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ = l.TryAcquire(ctx, "report"); !ok {
+		t.Fatal("released slot should be reusable")
+	}
+
+	// 持有者没有归还的租约过期后名额会被回收
+	clock.Advance(time.Minute)
+	if _, ok, _ = l.TryAcquire(ctx, "report"); !ok {
+		t.Fatal("expired leases should be reclaimed")
+	}
+}
+
+func TestMemoryConcurrencyLimiterRefresh(t *testing.T) {
+	clock := newFakeClock()
+	l := mustConcurrency(NewMemoryConcurrencyLimiter(1, time.Minute)).(*MemoryConcurrencyLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	lease, _, _ := l.TryAcquire(ctx, "k")
+	clock.Advance(50 * time.Second)
+	lease, err := l.Refresh(ctx, lease)
+	if err != nil || !lease.ExpireAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("refresh = %+v, %v", lease, err)
+	}
+
+	clock.Advance(50 * time.Second)
+	if _, ok, _ := l.TryAcquire(ctx, "k"); ok {
+		t.Fatal("refreshed lease should still hold the slot")
+	}
+
+	clock.Advance(time.Minute)
+	if _, err = l.Refresh(ctx, lease); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrLeaseNotFound)
+	}
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	l := mustConcurrency(NewMemoryConcurrencyLimiter(1, time.Minute))
+	ctx := context.Background()
+
+	lease, _ := l.Acquire(ctx, "k")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = l.Release(ctx, lease)
+	}()
+	if _, err := l.Acquire(ctx, "k"); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(timeout, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRedisConcurrencyLimiter(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	l := mustConcurrency(NewRedisConcurrencyLimiter(rdb, 2, time.Minute, WithKeyPrefix("cc:"))).(RedisConcurrencyLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	first, ok, err := l.TryAcquire(ctx, "report")
+	if !ok || err != nil {
+		t.Fatalf("first acquire: %v, %v", ok, err)
+	}
+	if ttl := mr.TTL("cc:report"); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want the lease ttl", ttl)
+	}
+	clock.Advance(10 * time.Second)
+	if _, ok, _ = l.TryAcquire(ctx, "report"); !ok {
+		t.Fatal("second acquire should succeed")
+	}
+	if _, ok, _ = l.TryAcquire(ctx, "report"); ok {
+		t.Fatal("third acquire should fail")
+	}
+
+	if err = l.Release(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ = l.TryAcquire(ctx, "report"); !ok {
+		t.Fatal("released slot should be reusable")
+	}
+
+	// 持有者没有归还的租约过期后名额会被回收
+	clock.Advance(time.Minute)
+	if _, ok, _ = l.TryAcquire(ctx, "report"); !ok {
+		t.Fatal("expired leases should be reclaimed")
+	}
+	if n := rdb.ZCard(ctx, "cc:report").Val(); n != 1 {
+		t.Fatalf("%d leases left, want the expired ones removed", n)
+	}
+}
+
+func TestRedisConcurrencyLimiterRefresh(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	l := mustConcurrency(NewRedisConcurrencyLimiter(rdb, 1, time.Minute)).(RedisConcurrencyLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	lease, _, _ := l.TryAcquire(ctx, "k")
+	clock.Advance(50 * time.Second)
+	lease, err := l.Refresh(ctx, lease)
+	if err != nil || !lease.ExpireAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("refresh = %+v, %v", lease, err)
+	}
+
+	clock.Advance(50 * time.Second)
+	if _, ok, _ := l.TryAcquire(ctx, "k"); ok {
+		t.Fatal("refreshed lease should still hold the slot")
+	}
+
+	clock.Advance(time.Minute)
+	if _, err = l.Refresh(ctx, lease); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrLeaseNotFound)
+	}
+	if _, ok, _ := l.TryAcquire(ctx, "k"); !ok {
+		t.Fatal("expired lease should be reclaimed")
+	}
+}
+
+func TestConcurrencyLimiterInvalid(t *testing.T) {
+	if _, err := NewMemoryConcurrencyLimiter(0, time.Minute); err == nil {
+		t.Fatal("expected an error for a zero limit")
+	}
+	if _, err := NewMemoryConcurrencyLimiter(1, time.Microsecond); err == nil {
+		t.Fatal("expected an error for a sub-millisecond lease")
+	}
+	if _, err := NewRedisConcurrencyLimiter(nil, -1, time.Minute); err == nil {
+		t.Fatal("expected an error for a negative limit")
+	}
+	if _, err := NewRedisConcurrencyLimiter(nil, 1, 0); err == nil {
+		t.Fatal("expected an error for a zero lease")
+	}
+}
@@ -0,0 +1,10 @@
+package ratelimit
+
+import "errors"
+
+var (
+	ErrInvalidCost   = errors.New("ratelimit: invalid cost")
+	ErrInvalidRule   = errors.New("ratelimit: invalid rule")
+	ErrLeaseNotFound = errors.New("ratelimit: lease not found")
+	ErrMissingKey    = errors.New("ratelimit: missing key")
+)
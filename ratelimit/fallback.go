@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailPolicy 主限流器（通常是 Redis）不可用时的处理方式
+type FailPolicy int
+
+const (
+	// FailOpen 放行所有请求
+	FailOpen FailPolicy = iota
+	// FailClosed 拒绝所有请求
+	FailClosed
+	// FailLocal 降级到进程内的限流器，每个实例各自限流，总量只是近似值
+	FailLocal
+)
+
+// defaultRetryInterval 主限流器出错后，至少隔多久再尝试使用它
+const defaultRetryInterval = time.Second
+
+type fallbackOptions struct {
+	local         Limiter
+	retryInterval time.Duration
+	onHealth      func(healthy bool, err error)
+}
+
+// FallbackOption 配置 NewFallbackLimiter 创建的限流器
+type FallbackOption func(*fallbackOptions)
+
+// WithLocalLimiter 设置 FailLocal 策略下使用的进程内限流器，
+// 一般按单个实例应分到的配额创建，例如总配额除以实例数
+func WithLocalLimiter(l Limiter) FallbackOption {
+	return func(opts *fallbackOptions) {
+		opts.local = l
+	}
+}
+
+// WithRetryInterval 设置主限流器出错后多久再尝试恢复，默认 1 秒，期间的请求直接按降级策略处理
+func WithRetryInterval(d time.Duration) FallbackOption {
+	return func(opts *fallbackOptions) {
+		if d > 0 {
+			opts.retryInterval = d
+		}
+	}
+}
+
+// WithHealthCallback 设置健康状态变化时的回调，可以用来告警。
+// 主限流器出错进入降级状态时 healthy 为 false，err 是导致降级的错误；恢复时 healthy 为 true。
+func WithHealthCallback(fn func(healthy bool, err error)) FallbackOption {
+	return func(opts *fallbackOptions) {
+		opts.onHealth = fn
+	}
+}
+
+// FallbackLimiter 在主限流器出错时按 FailPolicy 降级，而不是把错误交给每个调用方处理
+//
+// 主限流器出错后进入降级状态，每隔 retryInterval 只放一个请求去探测主限流器，
+// 探测成功后恢复正常。调用方取消 ctx 和 ErrInvalidCost 这类参数错误不会触发降级。
+type FallbackLimiter struct {
+	primary Limiter
+	policy  FailPolicy
+	opts    fallbackOptions
+	now     func() time.Time
+
+	mu      sync.Mutex
+	healthy bool
+	retryAt time.Time
+}
+
+// NewFallbackLimiter 创建带降级策略的限流器，policy 为 FailLocal 时必须通过 WithLocalLimiter 指定进程内限流器
+func NewFallbackLimiter(primary Limiter, policy FailPolicy, opts ...FallbackOption) (*FallbackLimiter, error) {
+	o := fallbackOptions{retryInterval: defaultRetryInterval}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	switch policy {
+	case FailOpen, FailClosed:
+	case FailLocal:
+		if o.local == nil {
+			return nil, errors.New("ratelimit: FailLocal requires a local limiter")
+		}
+	default:
+		return nil, fmt.Errorf("ratelimit: unsupported fail policy %d", policy)
+	}
+	return &FallbackLimiter{
+		primary: primary,
+		policy:  policy,
+		opts:    o,
+		now:     time.Now,
+		healthy: true,
+	}, nil
+}
+
+// Healthy 返回主限流器当前是否可用
+func (f *FallbackLimiter) Healthy() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.healthy
+}
+
+func (f *FallbackLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	return f.LimitN(ctx, key, 1)
+}
+
+// LimitN 一次消耗 n 个单位的配额，主限流器需要实现 WeightedLimiter
+func (f *FallbackLimiter) LimitN(ctx context.Context, key string, n int) (bool, error) {
+	d, err := f.DecideN(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+	return !d.Allowed, nil
+}
+
+// Decide 判断是否放行，降级期间按 FailPolicy 返回结果
+func (f *FallbackLimiter) Decide(ctx context.Context, key string) (Decision, error) {
+	return f.DecideN(ctx, key, 1)
+}
+
+// DecideN 和 Decide 一样，只是一次消耗 n 个单位的配额
+func (f *FallbackLimiter) DecideN(ctx context.Context, key string, n int) (Decision, error) {
+	if f.usePrimary() {
+		d, err := decideN(ctx, f.primary, key, n)
+		if err == nil {
+			f.setHealthy(true, nil)
+			return d, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrInvalidCost) {
+			return Decision{}, err
+		}
+		f.setHealthy(false, err)
+	}
+
+	switch f.policy {
+	case FailClosed:
+		return Decision{}, nil
+	case FailLocal:
+		return decideN(ctx, f.opts.local, key, n)
+	default:
+		return Decision{Allowed: true}, nil
+	}
+}
+
+// usePrimary 判断这次请求是否应该使用主限流器，降级期间每个 retryInterval 只放行一次探测
+func (f *FallbackLimiter) usePrimary() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.healthy {
+		return true
+	}
+	now := f.now()
+	if now.Before(f.retryAt) {
+		return false
+	}
+	f.retryAt = now.Add(f.opts.retryInterval)
+	return true
+}
+
+func (f *FallbackLimiter) setHealthy(healthy bool, err error) {
+	f.mu.Lock()
+	changed := f.healthy != healthy
+	f.healthy = healthy
+	if !healthy {
+		f.retryAt = f.now().Add(f.opts.retryInterval)
+	}
+	f.mu.Unlock()
+
+	if changed && f.opts.onHealth != nil {
+		f.opts.onHealth(healthy, err)
+	}
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyLimiter 在 down 为 true 时返回错误，否则总是触发限流
+type flakyLimiter struct {
+	down  atomic.Bool
+	calls atomic.Int64
+}
+
+func (f *flakyLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return false, errors.New("redis down")
+	}
+	return true, nil
+}
+
+func TestFallbackLimiterPolicies(t *testing.T) {
+	ctx := context.Background()
+	primary := &flakyLimiter{}
+	primary.down.Store(true)
+
+	open, _ := NewFallbackLimiter(primary, FailOpen)
+	if limited, err := open.Limit(ctx, "k"); limited || err != nil {
+		t.Fatalf("fail open = %v, %v", limited, err)
+	}
+
+	closed, _ := NewFallbackLimiter(primary, FailClosed)
+	if limited, err := closed.Limit(ctx, "k"); !limited || err != nil {
+		t.Fatalf("fail closed = %v, %v", limited, err)
+	}
+
+	local, err := NewFallbackLimiter(primary, FailLocal, WithLocalLimiter(mustLimiter(NewMemoryFixedWindowLimiter(time.Minute, 1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limited, _ := local.Limit(ctx, "k"); limited {
+		t.Fatal("first request should pass the local limiter")
+	}
+	if limited, _ := local.Limit(ctx, "k"); !limited {
+		t.Fatal("second request should be limited by the local limiter")
+	}
+
+	if _, err = NewFallbackLimiter(primary, FailLocal); err == nil {
+		t.Fatal("FailLocal without local limiter should fail")
+	}
+}
+
+func TestFallbackLimiterRecovers(t *testing.T) {
+	clock := newFakeClock()
+	ctx := context.Background()
+	primary := &flakyLimiter{}
+	primary.down.Store(true)
+
+	var events []bool
+	f, _ := NewFallbackLimiter(primary, FailOpen,
+		WithRetryInterval(time.Second),
+		WithHealthCallback(func(healthy bool, err error) {
+			events = append(events, healthy)
+		}))
+	f.now = clock.Now
+
+	for i := 0; i < 5; i++ {
+		_, _ = f.Limit(ctx, "k")
+	}
+	if f.Healthy() || primary.calls.Load() != 1 {
+		t.Fatalf("healthy = %v, calls = %d, want primary skipped while degraded", f.Healthy(), primary.calls.Load())
+	}
+
+	primary.down.Store(false)
+	clock.Advance(time.Second)
+	if limited, _ := f.Limit(ctx, "k"); !limited || !f.Healthy() {
+		t.Fatal("probe should reach the recovered primary")
+	}
+	if len(events) != 2 || events[0] || !events[1] {
+		t.Fatalf("events = %v, want [false true]", events)
+	}
+}
+
+func TestFallbackLimiterKeepsCallerErrors(t *testing.T) {
+	f, _ := NewFallbackLimiter(mustLimiter(NewMemorySlidingWindowLimiter(time.Second, 1)), FailOpen)
+	if _, err := f.LimitN(context.Background(), "k", 2); !errors.Is(err, ErrInvalidCost) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCost)
+	}
+	if !f.Healthy() {
+		t.Fatal("invalid cost should not mark the primary unhealthy")
+	}
+}
+
+func TestFallbackLimiterMiddlewareHeaders(t *testing.T) {
+	primary := &flakyLimiter{}
+	primary.down.Store(true)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// 降级期间没有配额信息，不能输出 X-RateLimit-Limit: 0 这类误导性的响应头
+	cases := []struct {
+		policy FailPolicy
+		status int
+	}{
+		{policy: FailOpen, status: http.StatusOK},
+		{policy: FailClosed, status: http.StatusTooManyRequests},
+	}
+	for _, c := range cases {
+		l, err := NewFallbackLimiter(primary, c.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		NewMiddleware(l)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != c.status {
+			t.Fatalf("policy %d: status = %d, want %d", c.policy, rec.Code, c.status)
+		}
+		for _, h := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"} {
+			if v := rec.Header().Get(h); v != "" {
+				t.Fatalf("policy %d: unexpected %s: %s", c.policy, h, v)
+			}
+		}
+	}
+
+	// 主限流器正常时输出它的配额信息
+	l, _ := NewFallbackLimiter(mustLimiter(NewMemorySlidingWindowLimiter(time.Minute, 5)), FailOpen)
+	rec := httptest.NewRecorder()
+	NewMiddleware(l)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Header().Get("X-RateLimit-Limit") != "5" || rec.Header().Get("X-RateLimit-Remaining") != "4" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+}
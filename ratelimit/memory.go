@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultMaxKeys 内存限流器默认最多保存的 key 数量
+const defaultMaxKeys = 10000
+
+var memoryLimiterSeq atomic.Uint64
+
+type memoryOptions struct {
+	maxKeys int
+}
+
+// MemoryOption 配置内存限流器
+type MemoryOption func(*memoryOptions)
+
+// WithMaxKeys 设置内存限流器最多保存多少个 key 的状态，超过后淘汰最久未访问的 key
+func WithMaxKeys(n int) MemoryOption {
+	return func(opts *memoryOptions) {
+		if n > 0 {
+			opts.maxKeys = n
+		}
+	}
+}
+
+type memoryEntry[S any] struct {
+	key      string
+	state    S
+	lastSeen time.Time
+}
+
+// memoryLimiter 是各种内存限流算法共用的部分：按 key 保存状态，
+// 空闲超过 idleTTL 或者 key 数量超过 maxKeys 时按 LRU 淘汰。
+//
+// idleTTL 取算法本身的状态有效期（窗口大小或者桶补满的时间），
+// 过了这段时间状态一定会回到初始值，所以淘汰不会影响限流结果。
+// 只有 key 数量超过 maxKeys 时，被提前淘汰的 key 才会拿到一份新的配额。
+type memoryLimiter[S any] struct {
+	// id 决定组合限流时的加锁顺序，避免死锁
+	id      uint64
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	lru     *list.List
+	maxKeys int
+	idleTTL time.Duration
+	limit   int
+	now     func() time.Time
+	// take 判断 st 是否还能放行 n 个单位，commit 为 false 时只判断不扣减
+	take func(st *S, now time.Time, n int, commit bool) Decision
+}
+
+func newMemoryLimiter[S any](limit int, idleTTL time.Duration,
+	take func(st *S, now time.Time, n int, commit bool) Decision, opts []MemoryOption) *memoryLimiter[S] {
+	o := memoryOptions{maxKeys: defaultMaxKeys}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return &memoryLimiter[S]{
+		id:      memoryLimiterSeq.Add(1),
+		items:   make(map[string]*list.Element),
+		lru:     list.New(),
+		maxKeys: o.maxKeys,
+		idleTTL: idleTTL,
+		limit:   limit,
+		now:     time.Now,
+		take:    take,
+	}
+}
+
+func (m *memoryLimiter[S]) Limit(ctx context.Context, key string) (bool, error) {
+	return m.LimitN(ctx, key, 1)
+}
+
+// LimitN 一次消耗 n 个单位的配额，配额不足时整体拒绝
+func (m *memoryLimiter[S]) LimitN(ctx context.Context, key string, n int) (bool, error) {
+	d, err := m.DecideN(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+	return !d.Allowed, nil
+}
+
+// Decide 判断是否放行，同时返回剩余配额、恢复时间和建议的重试时间
+func (m *memoryLimiter[S]) Decide(ctx context.Context, key string) (Decision, error) {
+	return m.DecideN(ctx, key, 1)
+}
+
+// DecideN 和 Decide 一样，只是一次消耗 n 个单位的配额
+func (m *memoryLimiter[S]) DecideN(ctx context.Context, key string, n int) (Decision, error) {
+	if err := ctx.Err(); err != nil {
+		return Decision{}, err
+	}
+	if n <= 0 || n > m.limit {
+		return Decision{}, fmt.Errorf("%w: cost %d, limit %d", ErrInvalidCost, n, m.limit)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	return m.take(m.state(key, now), now, n, true), nil
+}
+
+// reserve 判断 key 是否还能放行 n 个单位但不扣减，放行时返回扣减配额的函数，调用方需要持有 m.mu
+func (m *memoryLimiter[S]) reserve(key string, now time.Time, n int) (Decision, func(), error) {
+	if n <= 0 || n > m.limit {
+		return Decision{}, nil, fmt.Errorf("%w: cost %d, limit %d", ErrInvalidCost, n, m.limit)
+	}
+	st := m.state(key, now)
+	d := m.take(st, now, n, false)
+	if !d.Allowed {
+		return d, nil, nil
+	}
+	return d, func() { m.take(st, now, n, true) }, nil
+}
+
+func (m *memoryLimiter[S]) lockID() uint64 {
+	return m.id
+}
+
+func (m *memoryLimiter[S]) lock() {
+	m.mu.Lock()
+}
+
+func (m *memoryLimiter[S]) unlock() {
+	m.mu.Unlock()
+}
+
+// state 返回 key 对应的状态，不存在时创建一个新的，调用方需要持有 m.mu
+func (m *memoryLimiter[S]) state(key string, now time.Time) *S {
+	m.evict(now)
+	if elem, ok := m.items[key]; ok {
+		entry := elem.Value.(*memoryEntry[S])
+		entry.lastSeen = now
+		m.lru.MoveToFront(elem)
+		return &entry.state
+	}
+
+	for m.lru.Len() >= m.maxKeys {
+		m.remove(m.lru.Back())
+	}
+	entry := &memoryEntry[S]{key: key, lastSeen: now}
+	m.items[key] = m.lru.PushFront(entry)
+	return &entry.state
+}
+
+// evict 从链表尾部开始淘汰空闲超过 idleTTL 的 key，链表按访问时间排序，遇到没过期的就可以停下
+func (m *memoryLimiter[S]) evict(now time.Time) {
+	for elem := m.lru.Back(); elem != nil; elem = m.lru.Back() {
+		if now.Sub(elem.Value.(*memoryEntry[S]).lastSeen) <= m.idleTTL {
+			return
+		}
+		m.remove(elem)
+	}
+}
+
+func (m *memoryLimiter[S]) remove(elem *list.Element) {
+	m.lru.Remove(elem)
+	delete(m.items, elem.Value.(*memoryEntry[S]).key)
+}
+
+func (m *memoryLimiter[S]) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
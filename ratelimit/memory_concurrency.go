@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryConcurrencyLimiter 进程内的并发限流器，行为与 RedisConcurrencyLimiter 一致
+//
+// 没有租约的 key 会被删除，忘记 Release 的租约也会在过期后被定期清理，
+// 内存占用只和正在进行中的操作数量有关。
+type MemoryConcurrencyLimiter struct {
+	mu        sync.Mutex
+	leases    map[string]map[string]time.Time
+	limit     int
+	leaseTTL  time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryConcurrencyLimiter 创建进程内并发限流器，每个 key 最多同时发放 limit 个租约，租约有效期为 leaseTTL
+//
+// limit 必须大于 0，leaseTTL 不能小于 1 毫秒，否则返回错误。
+func NewMemoryConcurrencyLimiter(limit int, leaseTTL time.Duration) (ConcurrencyLimiter, error) {
+	if limit <= 0 || leaseTTL < time.Millisecond {
+		return nil, fmt.Errorf("ratelimit: invalid concurrency limit %d, lease ttl %v", limit, leaseTTL)
+	}
+	return &MemoryConcurrencyLimiter{
+		leases:   make(map[string]map[string]time.Time),
+		limit:    limit,
+		leaseTTL: leaseTTL,
+		now:      time.Now,
+	}, nil
+}
+
+func (m *MemoryConcurrencyLimiter) TryAcquire(ctx context.Context, key string) (Lease, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Lease{}, false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) > m.leaseTTL {
+		for k := range m.leases {
+			m.active(k, now)
+		}
+		m.lastSweep = now
+	}
+	leases := m.active(key, now)
+	if len(leases) >= m.limit {
+		return Lease{}, false, nil
+	}
+	if leases == nil {
+		leases = make(map[string]time.Time)
+		m.leases[key] = leases
+	}
+	lease := Lease{Key: key, ID: newLeaseID(), AcquiredAt: now, ExpireAt: now.Add(m.leaseTTL)}
+	leases[lease.ID] = lease.ExpireAt
+	return lease, true, nil
+}
+
+func (m *MemoryConcurrencyLimiter) Acquire(ctx context.Context, key string) (Lease, error) {
+	return waitAcquire(ctx, key, m.TryAcquire)
+}
+
+func (m *MemoryConcurrencyLimiter) Release(ctx context.Context, lease Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if leases, ok := m.leases[lease.Key]; ok {
+		delete(leases, lease.ID)
+		if len(leases) == 0 {
+			delete(m.leases, lease.Key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryConcurrencyLimiter) Refresh(ctx context.Context, lease Lease) (Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return Lease{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	leases := m.active(lease.Key, now)
+	if _, ok := leases[lease.ID]; !ok {
+		return Lease{}, ErrLeaseNotFound
+	}
+	lease.ExpireAt = now.Add(m.leaseTTL)
+	leases[lease.ID] = lease.ExpireAt
+	return lease, nil
+}
+
+// active 清理 key 下已经过期的租约并返回剩下的，调用方需要持有 m.mu
+func (m *MemoryConcurrencyLimiter) active(key string, now time.Time) map[string]time.Time {
+	leases, ok := m.leases[key]
+	if !ok {
+		return nil
+	}
+	for id, expireAt := range leases {
+		if !expireAt.After(now) {
+			delete(leases, id)
+		}
+	}
+	if len(leases) == 0 {
+		delete(m.leases, key)
+		return nil
+	}
+	return leases
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+type fixedWindowState struct {
+	start time.Time
+	count int
+}
+
+// MemoryFixedWindowLimiter 进程内的固定窗口限流器
+//
+// 窗口按 interval 对齐（例如 interval 为一分钟时，窗口从每分钟的 0 秒开始），
+// 实现最简单、开销最小，但在窗口边界处最多可能放行 2*rate 个请求。
+type MemoryFixedWindowLimiter struct {
+	*memoryLimiter[fixedWindowState]
+}
+
+// NewMemoryFixedWindowLimiter 创建进程内固定窗口限流器，每个 interval 窗口内最多放行 rate 个请求
+//
+// interval、rate 都必须大于 0，否则返回错误。
+func NewMemoryFixedWindowLimiter(interval time.Duration, rate int, opts ...MemoryOption) (Limiter, error) {
+	if interval <= 0 || rate <= 0 {
+		return nil, fmt.Errorf("ratelimit: invalid fixed window interval %v, rate %d", interval, rate)
+	}
+	take := func(st *fixedWindowState, now time.Time, n int, commit bool) Decision {
+		start := now.Truncate(interval)
+		if !st.start.Equal(start) {
+			// 进入了新的窗口
+			st.start = start
+			st.count = 0
+		}
+
+		resetAt := start.Add(interval)
+		if st.count+n > rate {
+			return Decision{
+				Limit:      rate,
+				Remaining:  rate - st.count,
+				ResetAt:    resetAt,
+				RetryAfter: resetAt.Sub(now),
+			}
+		}
+
+		d := Decision{Allowed: true, Limit: rate, Remaining: rate - st.count - n, ResetAt: resetAt}
+		if commit {
+			st.count += n
+		}
+		return d
+	}
+	return &MemoryFixedWindowLimiter{newMemoryLimiter(rate, interval, take, opts)}, nil
+}
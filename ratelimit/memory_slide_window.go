@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+type windowEntry struct {
+	at   time.Time
+	cost int
+}
+
+type slidingWindowState struct {
+	entries []windowEntry
+	total   int
+}
+
+// MemorySlidingWindowLimiter 进程内的滑动窗口限流器，行为与 RedisSlidingWindowLimiter 一致
+type MemorySlidingWindowLimiter struct {
+	*memoryLimiter[slidingWindowState]
+}
+
+// NewMemorySlidingWindowLimiter 创建进程内滑动窗口限流器，任意 interval 时间内最多放行 rate 个请求
+//
+// interval、rate 都必须大于 0，否则返回错误。
+func NewMemorySlidingWindowLimiter(interval time.Duration, rate int, opts ...MemoryOption) (Limiter, error) {
+	if interval <= 0 || rate <= 0 {
+		return nil, fmt.Errorf("ratelimit: invalid sliding window interval %v, rate %d", interval, rate)
+	}
+	take := func(st *slidingWindowState, now time.Time, n int, commit bool) Decision {
+		// 移除已经滑出窗口的请求
+		windowStart := now.Add(-interval)
+		expired := 0
+		for expired < len(st.entries) && !st.entries[expired].at.After(windowStart) {
+			st.total -= st.entries[expired].cost
+			expired++
+		}
+		st.entries = st.entries[expired:]
+
+		if st.total+n > rate {
+			// 从最早的请求开始，找到过期后能腾出足够名额的那一个
+			d := Decision{Limit: rate, Remaining: rate - st.total, ResetAt: now.Add(interval)}
+			if last := len(st.entries) - 1; last >= 0 {
+				d.ResetAt = st.entries[last].at.Add(interval)
+			}
+			freed := 0
+			for _, entry := range st.entries {
+				freed += entry.cost
+				if st.total-freed+n <= rate {
+					d.RetryAfter = entry.at.Add(interval).Sub(now)
+					break
+				}
+			}
+			return d
+		}
+
+		d := Decision{Allowed: true, Limit: rate, Remaining: rate - st.total - n, ResetAt: now.Add(interval)}
+		if commit {
+			st.entries = append(st.entries, windowEntry{at: now, cost: n})
+			st.total += n
+		}
+		return d
+	}
+	return &MemorySlidingWindowLimiter{newMemoryLimiter(rate, interval, take, opts)}, nil
+}
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// mustLimiter 返回 l，构造限流器失败时直接 panic
+func mustLimiter(l Limiter, err error) Limiter {
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func TestMemorySlidingWindowLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := mustLimiter(NewMemorySlidingWindowLimiter(time.Second, 3)).(*MemorySlidingWindowLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		d, err := l.Decide(ctx, "k")
+		if err != nil || !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("decision %d = %+v, %v", i, d, err)
+		}
+		clock.Advance(100 * time.Millisecond)
+	}
+	d, _ := l.Decide(ctx, "k")
+	if d.Allowed || d.RetryAfter != 700*time.Millisecond {
+		t.Fatalf("decision = %+v, want limited with retry after 700ms", d)
+	}
+
+	// 其他 key 不受影响
+	if limited, _ := l.Limit(ctx, "other"); limited {
+		t.Fatal("other key should not be limited")
+	}
+
+	clock.Advance(700 * time.Millisecond)
+	if limited, _ := l.Limit(ctx, "k"); limited {
+		t.Fatal("oldest request should have left the window")
+	}
+}
+
+func TestMemoryFixedWindowLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := mustLimiter(NewMemoryFixedWindowLimiter(time.Minute, 2)).(*MemoryFixedWindowLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	clock.Advance(50 * time.Second)
+	for i := 0; i < 2; i++ {
+		if limited, _ := l.Limit(ctx, "k"); limited {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	d, _ := l.Decide(ctx, "k")
+	if d.Allowed || d.RetryAfter != 10*time.Second {
+		t.Fatalf("decision = %+v, want limited until next window", d)
+	}
+
+	clock.Advance(10 * time.Second)
+	if limited, _ := l.Limit(ctx, "k"); limited {
+		t.Fatal("new window should reset the counter")
+	}
+}
+
+func TestMemoryTokenBucketLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := mustLimiter(NewMemoryTokenBucketLimiter(4, time.Second, 2)).(*MemoryTokenBucketLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	// 满桶允许突发
+	if limited, err := l.LimitN(ctx, "k", 4); limited || err != nil {
+		t.Fatalf("burst should be allowed: %v, %v", limited, err)
+	}
+	d, _ := l.Decide(ctx, "k")
+	if d.Allowed || d.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("decision = %+v, want limited with retry after 500ms", d)
+	}
+
+	clock.Advance(time.Second)
+	d, _ = l.DecideN(ctx, "k", 2)
+	if !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("decision = %+v, want 2 refilled tokens consumed", d)
+	}
+
+	if _, err := l.LimitN(ctx, "k", 5); !errors.Is(err, ErrInvalidCost) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCost)
+	}
+}
+
+func TestMemoryLimiterEvictsKeys(t *testing.T) {
+	clock := newFakeClock()
+	l := mustLimiter(NewMemorySlidingWindowLimiter(time.Second, 1, WithMaxKeys(2))).(*MemorySlidingWindowLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _ = l.Limit(ctx, key)
+	}
+	if got := l.len(); got != 2 {
+		t.Fatalf("len = %d, want 2", got)
+	}
+
+	clock.Advance(2 * time.Second)
+	_, _ = l.Limit(ctx, "d")
+	if got := l.len(); got != 1 {
+		t.Fatalf("len = %d, want idle keys to be evicted", got)
+	}
+}
+
+func TestMemoryLimiterConcurrent(t *testing.T) {
+	l := mustLimiter(NewMemoryFixedWindowLimiter(time.Minute, 100)).(*MemoryFixedWindowLimiter)
+	l.now = newFakeClock().Now
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if limited, _ := l.Limit(ctx, "k"); !limited {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 100 {
+		t.Fatalf("allowed = %d, want 100", allowed)
+	}
+}
+
+func TestMemoryLimiterInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func() (Limiter, error)
+	}{
+		{name: "token bucket zero rate", new: func() (Limiter, error) { return NewMemoryTokenBucketLimiter(10, time.Second, 0) }},
+		{name: "token bucket zero capacity", new: func() (Limiter, error) { return NewMemoryTokenBucketLimiter(0, time.Second, 10) }},
+		{name: "token bucket zero interval", new: func() (Limiter, error) { return NewMemoryTokenBucketLimiter(10, 0, 10) }},
+		{name: "fixed window zero rate", new: func() (Limiter, error) { return NewMemoryFixedWindowLimiter(time.Second, 0) }},
+		{name: "fixed window negative interval", new: func() (Limiter, error) { return NewMemoryFixedWindowLimiter(-time.Second, 10) }},
+		{name: "sliding window zero rate", new: func() (Limiter, error) { return NewMemorySlidingWindowLimiter(time.Second, 0) }},
+		{name: "sliding window zero interval", new: func() (Limiter, error) { return NewMemorySlidingWindowLimiter(0, 10) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.new(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
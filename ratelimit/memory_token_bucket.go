@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type tokenBucketState struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryTokenBucketLimiter 进程内的令牌桶限流器，行为与 RedisTokenBucketLimiter 一致
+type MemoryTokenBucketLimiter struct {
+	*memoryLimiter[tokenBucketState]
+}
+
+// NewMemoryTokenBucketLimiter 创建进程内令牌桶限流器，桶容量为 capacity，每 interval 补充 rate 个令牌
+//
+// capacity、interval、rate 都必须大于 0，否则返回错误。
+func NewMemoryTokenBucketLimiter(capacity int, interval time.Duration, rate int, opts ...MemoryOption) (Limiter, error) {
+	if capacity <= 0 || interval <= 0 || rate <= 0 {
+		return nil, fmt.Errorf("ratelimit: invalid token bucket capacity %d, interval %v, rate %d", capacity, interval, rate)
+	}
+	// 每个令牌的补充时间
+	perToken := float64(interval) / float64(rate)
+	take := func(st *tokenBucketState, now time.Time, n int, commit bool) Decision {
+		// 第一次访问时桶是满的
+		tokens, last := float64(capacity), now
+		if !st.last.IsZero() {
+			tokens, last = st.tokens, st.last
+		}
+		// 时间倒退时不补充令牌
+		if now.After(last) {
+			tokens = math.Min(float64(capacity), tokens+float64(now.Sub(last))/perToken)
+			last = now
+		}
+
+		allowed := tokens >= float64(n)
+		left := tokens
+		if allowed {
+			left -= float64(n)
+		}
+		if commit {
+			st.tokens, st.last = left, last
+		}
+
+		d := Decision{
+			Allowed:   allowed,
+			Limit:     capacity,
+			Remaining: int(math.Floor(left)),
+			ResetAt:   now.Add(time.Duration(math.Ceil((float64(capacity) - left) * perToken))),
+		}
+		if !allowed {
+			d.RetryAfter = time.Duration(math.Ceil((float64(n) - tokens) * perToken))
+		}
+		return d
+	}
+	idleTTL := time.Duration(math.Ceil(float64(capacity) * perToken))
+	return &MemoryTokenBucketLimiter{newMemoryLimiter(capacity, idleTTL, take, opts)}, nil
+}
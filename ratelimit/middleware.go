@@ -0,0 +1,305 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linorwang/goaid/resp"
+)
+
+// KeyFunc 从请求中提取限流 key，请求中没有对应的值时返回 ErrMissingKey，
+// 返回空字符串等同于返回 ErrMissingKey
+type KeyFunc func(r *http.Request) (string, error)
+
+type middlewareOptions struct {
+	keyFunc     KeyFunc
+	onRejected  func(w http.ResponseWriter, r *http.Request, d Decision)
+	onError     func(w http.ResponseWriter, r *http.Request, err error)
+	headers     bool
+	skipMissing bool
+}
+
+// MiddlewareOption 配置 NewMiddleware 创建的中间件
+type MiddlewareOption func(*middlewareOptions)
+
+// WithKeyFunc 设置限流 key 的提取方式，默认使用不信任任何代理的 ClientIPKey
+func WithKeyFunc(fn KeyFunc) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if fn != nil {
+			opts.keyFunc = fn
+		}
+	}
+}
+
+// WithRejectHandler 设置被限流时的响应，默认返回 429 和 resp.Result 格式的 JSON
+func WithRejectHandler(fn func(w http.ResponseWriter, r *http.Request, d Decision)) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if fn != nil {
+			opts.onRejected = fn
+		}
+	}
+}
+
+// WithErrorHandler 设置提取 key 或者限流器出错时的处理方式，
+// 默认在缺少 key（ErrMissingKey）时返回 400，其他错误返回 500。
+// 想在出错时放行请求，可以在 fn 里直接调用后续的 handler。
+func WithErrorHandler(fn func(w http.ResponseWriter, r *http.Request, err error)) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if fn != nil {
+			opts.onError = fn
+		}
+	}
+}
+
+// WithSkipMissingKey 放行提取不到 key 的请求，不对它们限流。
+// 默认这些请求会交给 WithErrorHandler 处理，避免客户端不带请求头就能绕过限流；
+// 想让这些请求按 IP 限流，可以使用 FirstKey(HeaderKey("X-API-Key"), ClientIPKey())
+func WithSkipMissingKey() MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		opts.skipMissing = true
+	}
+}
+
+// WithoutHeaders 不输出 X-RateLimit-* 响应头，被限流时仍然会输出 Retry-After
+func WithoutHeaders() MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		opts.headers = false
+	}
+}
+
+// NewMiddleware 创建 net/http 限流中间件
+//
+// 如果 l 实现了 DecisionLimiter，中间件会输出 X-RateLimit-Limit、X-RateLimit-Remaining、
+// X-RateLimit-Reset（配额恢复时间的 Unix 秒数）响应头，被限流时还会输出 Retry-After。
+func NewMiddleware(l Limiter, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := middlewareOptions{
+		keyFunc:    ClientIPKey(),
+		onRejected: writeRejected,
+		onError:    writeError,
+		headers:    true,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := o.keyFunc(r)
+			if err == nil && key == "" {
+				err = ErrMissingKey
+			}
+			if err != nil {
+				if o.skipMissing && errors.Is(err, ErrMissingKey) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				o.onError(w, r, err)
+				return
+			}
+
+			d, hasDetail, err := decide(r.Context(), l, key)
+			if err != nil {
+				o.onError(w, r, err)
+				return
+			}
+			if hasDetail && o.headers {
+				setRateLimitHeaders(w.Header(), d)
+			}
+			if !d.Allowed {
+				if d.RetryAfter > 0 {
+					w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(d.RetryAfter), 10))
+				}
+				o.onRejected(w, r, d)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decide 优先使用 DecisionLimiter 拿到详细结果，hasDetail 表示 Decision 中除 Allowed 以外的字段是否有效。
+// DecisionLimiter 也可能没有详细结果，例如 FallbackLimiter 降级期间，这时 Limit 为 0
+func decide(ctx context.Context, l Limiter, key string) (d Decision, hasDetail bool, err error) {
+	if dl, ok := l.(DecisionLimiter); ok {
+		d, err = dl.Decide(ctx, key)
+		return d, d.Limit > 0 && !d.ResetAt.IsZero(), err
+	}
+	limited, err := l.Limit(ctx, key)
+	return Decision{Allowed: !limited}, false, err
+}
+
+func setRateLimitHeaders(h http.Header, d Decision) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(max(d.Remaining, 0)))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt((d.ResetAt.UnixMilli()+999)/1000, 10))
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+func writeRejected(w http.ResponseWriter, r *http.Request, d Decision) {
+	writeResult(w, http.StatusTooManyRequests, "too many requests")
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrMissingKey) {
+		writeResult(w, http.StatusBadRequest, "missing rate limit key")
+		return
+	}
+	writeResult(w, http.StatusInternalServerError, "rate limiter unavailable")
+}
+
+func writeResult(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp.Result{Code: status, Msg: msg})
+}
+
+// ClientIPKey 使用客户端 IP 作为限流 key
+//
+// 只有当直接连接的对端地址属于 trustedProxies 时才会读取 X-Forwarded-For，
+// 并从右往左跳过可信代理，取第一个不可信的地址，避免客户端伪造请求头绕过限流。
+// trustedProxies 可以是单个 IP 或者 CIDR，格式错误时会 panic。
+func ClientIPKey(trustedProxies ...string) KeyFunc {
+	prefixes := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			panic(fmt.Sprintf("ratelimit: invalid trusted proxy %q: %v", proxy, err))
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(r *http.Request) (string, error) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		remote, err := netip.ParseAddr(host)
+		if err != nil {
+			return "", fmt.Errorf("ratelimit: invalid remote address %q: %w", r.RemoteAddr, err)
+		}
+		remote = remote.Unmap()
+		if !trusted(remote) {
+			return remote.String(), nil
+		}
+
+		client := remote
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// 无法解析的地址可能是伪造的，停在最后一个可信的地址上
+				break
+			}
+			client = addr.Unmap()
+			if !trusted(client) {
+				break
+			}
+		}
+		return client.String(), nil
+	}
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// HeaderKey 使用请求头 name 的值作为限流 key，例如 X-API-Key；
+// 请求头不存在或者为空时返回 ErrMissingKey
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		if v := r.Header.Get(name); v != "" {
+			return v, nil
+		}
+		return "", ErrMissingKey
+	}
+}
+
+// SubjectKey 使用已认证用户的标识作为限流 key，subject 从请求的 context 中取出用户标识，
+// 通常由前面的认证中间件写入；未认证的请求返回 ErrMissingKey
+func SubjectKey(subject func(ctx context.Context) (string, bool)) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		sub, ok := subject(r.Context())
+		if !ok || sub == "" {
+			return "", ErrMissingKey
+		}
+		return sub, nil
+	}
+}
+
+// RouteKey 使用 http.ServeMux 匹配到的路由模式（例如 "GET /users/{id}"）作为限流 key，
+// 没有匹配模式时退回到请求路径
+func RouteKey() KeyFunc {
+	return func(r *http.Request) (string, error) {
+		if r.Pattern != "" {
+			return r.Pattern, nil
+		}
+		return r.URL.Path, nil
+	}
+}
+
+// FirstKey 依次尝试 fns，返回第一个提取到的 key，都提取不到时返回 ErrMissingKey，
+// 例如 FirstKey(HeaderKey("X-API-Key"), ClientIPKey()) 在没有 API Key 时按 IP 限流
+func FirstKey(fns ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		for _, fn := range fns {
+			key, err := fn(r)
+			if errors.Is(err, ErrMissingKey) {
+				continue
+			}
+			if err != nil || key != "" {
+				return key, err
+			}
+		}
+		return "", ErrMissingKey
+	}
+}
+
+// JoinKeys 把多个 key 用冒号拼接起来，例如 JoinKeys(RouteKey(), ClientIPKey()) 按路由和 IP 分别限流；
+// 任意一个 key 提取不到时返回 ErrMissingKey
+func JoinKeys(fns ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		parts := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			key, err := fn(r)
+			if err != nil {
+				return "", err
+			}
+			if key == "" {
+				return "", ErrMissingKey
+			}
+			parts = append(parts, key)
+		}
+		return strings.Join(parts, ":"), nil
+	}
+}
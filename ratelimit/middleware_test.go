@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/linorwang/goaid/resp"
+)
+
+func TestMiddlewareRejectsWithHeaders(t *testing.T) {
+	l := mustLimiter(NewMemorySlidingWindowLimiter(time.Minute, 2))
+	h := NewMiddleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("request %d status = %d", i, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(1-i) {
+			t.Fatalf("request %d remaining = %q", i, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" || rec.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	var body resp.Result
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Code != http.StatusTooManyRequests {
+		t.Fatalf("body = %+v, %v", body, err)
+	}
+}
+
+type errLimiter struct{}
+
+func (errLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	return false, errors.New("redis down")
+}
+
+func TestMiddlewareErrorHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	NewMiddleware(errLimiter{})(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	failOpen := WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		next.ServeHTTP(w, r)
+	})
+	NewMiddleware(errLimiter{}, failOpen)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestClientIPKey(t *testing.T) {
+	cases := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     string
+		want    string
+	}{
+		{name: "untrusted peer ignores header", remote: "203.0.113.9:1234", xff: "1.1.1.1", want: "203.0.113.9"},
+		{name: "trusted proxy", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.1:80", xff: "198.51.100.7", want: "198.51.100.7"},
+		{name: "spoofed left-most hop", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.1:80", xff: "1.1.1.1, 198.51.100.7, 10.0.0.2", want: "198.51.100.7"},
+		{name: "all hops trusted", trusted: []string{"10.0.0.1", "10.0.0.2"}, remote: "10.0.0.1:80", xff: "10.0.0.2", want: "10.0.0.2"},
+		{name: "garbage hop", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.1:80", xff: "nope", want: "10.0.0.1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = c.remote
+			if c.xff != "" {
+				r.Header.Set("X-Forwarded-For", c.xff)
+			}
+			got, err := ClientIPKey(c.trusted...)(r)
+			if err != nil || got != c.want {
+				t.Fatalf("key = %q, %v, want %q", got, err, c.want)
+			}
+		})
+	}
+}
+
+func TestKeyComposition(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+
+	key, _ := FirstKey(HeaderKey("X-API-Key"), ClientIPKey())(r)
+	if key != "192.0.2.1" {
+		t.Fatalf("key = %q, want fallback to client IP", key)
+	}
+	r.Header.Set("X-API-Key", "abc")
+	key, _ = FirstKey(HeaderKey("X-API-Key"), ClientIPKey())(r)
+	if key != "abc" {
+		t.Fatalf("key = %q, want header value", key)
+	}
+
+	key, _ = JoinKeys(RouteKey(), ClientIPKey())(r)
+	if key != "/users/1:192.0.2.1" {
+		t.Fatalf("key = %q", key)
+	}
+
+	type subjectKey struct{}
+	subject := SubjectKey(func(ctx context.Context) (string, bool) {
+		sub, ok := ctx.Value(subjectKey{}).(string)
+		return sub, ok
+	})
+	if _, err := subject(r); !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("anonymous err = %v, want ErrMissingKey", err)
+	}
+	if key, _ = FirstKey(subject, ClientIPKey())(r); key != "192.0.2.1" {
+		t.Fatalf("anonymous key = %q, want fallback to client IP", key)
+	}
+	r = r.WithContext(context.WithValue(r.Context(), subjectKey{}, "user-1"))
+	if key, _ = subject(r); key != "user-1" {
+		t.Fatalf("subject key = %q", key)
+	}
+	if _, err := JoinKeys(RouteKey(), HeaderKey("X-Tenant"))(r); !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("join with missing part err = %v, want ErrMissingKey", err)
+	}
+}
+
+func TestMiddlewareMissingKey(t *testing.T) {
+	l := mustLimiter(NewMemoryFixedWindowLimiter(time.Minute, 1))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	type subjectKey struct{}
+	subject := SubjectKey(func(ctx context.Context) (string, bool) {
+		sub, ok := ctx.Value(subjectKey{}).(string)
+		return sub, ok
+	})
+
+	// 不带请求头、未认证的请求不能绕过限流
+	for _, fn := range []KeyFunc{HeaderKey("X-API-Key"), subject} {
+		rec := httptest.NewRecorder()
+		NewMiddleware(l, WithKeyFunc(fn))(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", rec.Code)
+		}
+	}
+
+	h := NewMiddleware(l, WithKeyFunc(HeaderKey("X-API-Key")), WithSkipMissingKey())(next)
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("skipped request %d status = %d", i, rec.Code)
+		}
+	}
+
+	// 按 IP 兜底时，匿名请求共享同一个 IP 的配额
+	h = NewMiddleware(l, WithKeyFunc(FirstKey(subject, ClientIPKey())))(next)
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, rec.Code)
+	}
+	if codes[0] != http.StatusNoContent || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("anonymous statuses = %v", codes)
+	}
+}
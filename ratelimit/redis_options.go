@@ -0,0 +1,52 @@
+package ratelimit
+
+import "github.com/redis/go-redis/v9"
+
+type redisOptions struct {
+	prefix  string
+	hashTag string
+}
+
+// RedisOption 配置 Redis 限流器
+type RedisOption func(*redisOptions)
+
+// WithKeyPrefix 给所有 Redis key 加上前缀，例如 "myapp:ratelimit:"
+func WithKeyPrefix(prefix string) RedisOption {
+	return func(opts *redisOptions) {
+		opts.prefix = prefix
+	}
+}
+
+// WithHashTag 把这个限流器的所有 key 都放到 {tag} 对应的 slot 中
+//
+// Redis Cluster 要求一个脚本访问的所有 key 在同一个 slot，组合限流时各条规则的 key 各不相同，
+// 必须给所有 Redis 规则设置相同的 hash tag 才能在一个脚本中执行。代价是这些 key 都会集中在一个节点上。
+func WithHashTag(tag string) RedisOption {
+	return func(opts *redisOptions) {
+		opts.hashTag = tag
+	}
+}
+
+func newRedisOptions(opts []RedisOption) redisOptions {
+	var o redisOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// key 生成调用方 key 对应的 Redis key
+func (o redisOptions) key(key string) string {
+	if o.hashTag != "" {
+		return o.prefix + "{" + o.hashTag + "}" + key
+	}
+	return o.prefix + key
+}
+
+// isCluster 判断 cmd 是否连接的是 Redis Cluster
+func isCluster(cmd redis.Cmdable) bool {
+	_, ok := cmd.(*redis.ClusterClient)
+	return ok
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/linorwang/goaid/internal/hashtag"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisKeys(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []RedisOption
+		key  string
+		want []string
+	}{
+		{name: "plain", key: "user:1", want: []string{"user:1", "{user:1}:total"}},
+		{name: "prefix", opts: []RedisOption{WithKeyPrefix("app:")}, key: "user:1", want: []string{"app:user:1", "{app:user:1}:total"}},
+		{name: "caller hash tag", key: "{tenant}:user:1", want: []string{"{tenant}:user:1", "{tenant}:user:1:total"}},
+		{name: "empty hash tag", key: "a{}b"},
+		{name: "closing brace", key: "user:}1"},
+		{name: "limiter hash tag", opts: []RedisOption{WithKeyPrefix("rl:"), WithHashTag("shared")}, key: "user:1",
+			want: []string{"rl:{shared}user:1", "rl:{shared}user:1:total"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := NewRedisSlidingWindowLimiter(nil, time.Second, 1, c.opts...).(RedisSlidingWindowLimiter)
+			got := l.windowKeys(c.key)
+			if c.want != nil && (len(got) != 2 || got[0] != c.want[0] || got[1] != c.want[1]) {
+				t.Fatalf("keys = %q, want %q", got, c.want)
+			}
+			if hashtag.Slot(got[0]) != hashtag.Slot(got[1]) {
+				t.Fatalf("keys %q are in slots %d and %d", got, hashtag.Slot(got[0]), hashtag.Slot(got[1]))
+			}
+		})
+	}
+}
+
+func TestCompositeLimiterRequiresHashTagOnCluster(t *testing.T) {
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:0"}})
+	defer cluster.Close()
+
+	bucket, err := NewRedisTokenBucketLimiter(cluster, 10, time.Second, 10)
+	if err != nil {
+		t.Fatalf("NewRedisTokenBucketLimiter failed: %v", err)
+	}
+	_, err = NewCompositeLimiter(
+		Rule{Limiter: NewRedisSlidingWindowLimiter(cluster, time.Second, 10)},
+		Rule{Limiter: bucket},
+	)
+	if !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidRule)
+	}
+
+	bucket, err = NewRedisTokenBucketLimiter(cluster, 10, time.Second, 10, WithHashTag("api"))
+	if err != nil {
+		t.Fatalf("NewRedisTokenBucketLimiter failed: %v", err)
+	}
+	_, err = NewCompositeLimiter(
+		Rule{Limiter: NewRedisSlidingWindowLimiter(cluster, time.Second, 10, WithHashTag("api"))},
+		Rule{Limiter: bucket},
+	)
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+}
+
+// newTestRedis 启动一个 miniredis，测试结束时自动关闭
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
@@ -2,29 +2,114 @@ package ratelimit
 
 import (
 	"context"
+	"crypto/rand"
 	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"github.com/linorwang/goaid/internal/hashtag"
 	"github.com/redis/go-redis/v9"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
-//go:embed slide_window.lua
-var luaSlideWindow string
+var (
+	// 滑动窗口算法，和组合限流共用
+	//go:embed window_check.lua
+	luaWindowCheck string
+	//go:embed slide_window.lua
+	luaSlideWindow string
+
+	slideWindowScript = redis.NewScript(luaWindowCheck + luaSlideWindow)
+)
 
 type RedisSlidingWindowLimiter struct {
 	cmd      redis.Cmdable
 	interval time.Duration
 	rate     int
+	opts     redisOptions
+	now      func() time.Time
 }
 
-func NewRedisSlidingWindowLimiter(cmd redis.Cmdable, interval time.Duration, rate int) Limiter {
+func NewRedisSlidingWindowLimiter(cmd redis.Cmdable, interval time.Duration, rate int, opts ...RedisOption) Limiter {
 	return RedisSlidingWindowLimiter{
 		cmd:      cmd,
 		interval: interval,
 		rate:     rate,
+		opts:     newRedisOptions(opts),
+		now:      time.Now,
 	}
 }
 
 func (r RedisSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
-	return r.cmd.Eval(ctx, luaSlideWindow, []string{key},
-		r.interval.Milliseconds(), r.rate, time.Now().UnixMilli()).Bool()
+	return r.LimitN(ctx, key, 1)
+}
+
+// LimitN 一次消耗 n 个单位的配额，窗口内放不下时整体拒绝
+func (r RedisSlidingWindowLimiter) LimitN(ctx context.Context, key string, n int) (bool, error) {
+	d, err := r.DecideN(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+	return !d.Allowed, nil
+}
+
+// Decide 判断是否放行，同时返回剩余名额、窗口恢复时间和建议的重试时间
+func (r RedisSlidingWindowLimiter) Decide(ctx context.Context, key string) (Decision, error) {
+	return r.DecideN(ctx, key, 1)
+}
+
+// DecideN 和 Decide 一样，只是一次消耗 n 个单位的配额
+func (r RedisSlidingWindowLimiter) DecideN(ctx context.Context, key string, n int) (Decision, error) {
+	if n <= 0 || n > r.rate {
+		return Decision{}, fmt.Errorf("%w: cost %d, rate %d", ErrInvalidCost, n, r.rate)
+	}
+	now := r.now()
+	vals, err := slideWindowScript.Run(ctx, r.cmd, r.windowKeys(key),
+		r.interval.Milliseconds(), r.rate, now.UnixMilli(), n, windowMember(now, n)).Int64Slice()
+	if err != nil {
+		return Decision{}, err
+	}
+	return newDecision(now, r.rate, vals)
+}
+
+func (r RedisSlidingWindowLimiter) redisClient() redis.Cmdable {
+	return r.cmd
+}
+
+func (r RedisSlidingWindowLimiter) hashTag() string {
+	return r.opts.hashTag
+}
+
+func (r RedisSlidingWindowLimiter) compositeRule(key string, now time.Time, n int) compositeRule {
+	return compositeRule{
+		kind:  "sw",
+		keys:  r.windowKeys(key),
+		args:  []any{r.interval.Milliseconds(), r.rate, n, windowMember(now, n)},
+		limit: r.rate,
+	}
+}
+
+// windowKeys 返回滑动窗口使用的 ZSET 和权重总数两个 key，两者在同一个 slot
+func (r RedisSlidingWindowLimiter) windowKeys(key string) []string {
+	key = r.opts.key(key)
+	return []string{key, hashtag.SameSlot(key, ":total")}
+}
+
+var (
+	// memberPrefix 区分不同进程，memberSeq 区分同一进程内的请求
+	memberPrefix = newMemberPrefix()
+	memberSeq    atomic.Uint64
+)
+
+func newMemberPrefix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// windowMember 生成滑动窗口 ZSET 的成员名，格式是 "时间:唯一ID:权重"
+func windowMember(now time.Time, cost int) string {
+	return strconv.FormatInt(now.UnixMilli(), 10) + ":" + memberPrefix +
+		strconv.FormatUint(memberSeq.Add(1), 36) + ":" + strconv.Itoa(cost)
 }
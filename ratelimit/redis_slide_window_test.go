@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestSlidingWindow(t *testing.T, interval time.Duration, rate int) (RedisSlidingWindowLimiter, *fakeClock) {
+	t.Helper()
+	_, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	l := NewRedisSlidingWindowLimiter(rdb, interval, rate).(RedisSlidingWindowLimiter)
+	l.now = clock.Now
+	return l, clock
+}
+
+func TestRedisSlidingWindowLimiterWeighted(t *testing.T) {
+	l, clock := newTestSlidingWindow(t, time.Second, 5)
+	ctx := context.Background()
+	keys := l.windowKeys("k")
+
+	if limited, err := l.LimitN(ctx, "k", 3); limited || err != nil {
+		t.Fatalf("first request: %v, %v", limited, err)
+	}
+	clock.Advance(100 * time.Millisecond)
+	// 放不下时整体拒绝，不会只扣一部分
+	if limited, _ := l.LimitN(ctx, "k", 3); !limited {
+		t.Fatal("3 units requested with only 2 left should be limited")
+	}
+	if limited, _ := l.LimitN(ctx, "k", 2); limited {
+		t.Fatal("the remaining 2 units should be allowed")
+	}
+	if total, _ := l.cmd.Get(ctx, keys[1]).Int(); total != 5 {
+		t.Fatalf("total = %d, want 5", total)
+	}
+
+	// 第一次请求过期后腾出 3 个单位
+	clock.Advance(900 * time.Millisecond)
+	if limited, _ := l.LimitN(ctx, "k", 4); !limited {
+		t.Fatal("only 3 units should have been freed")
+	}
+	if limited, _ := l.LimitN(ctx, "k", 3); limited {
+		t.Fatal("3 freed units should be allowed")
+	}
+
+	// 总数丢失时从 ZSET 重新统计
+	l.cmd.Del(ctx, keys[1])
+	if limited, _ := l.Limit(ctx, "k"); !limited {
+		t.Fatal("total should be recounted from the window")
+	}
+
+	if _, err := l.LimitN(ctx, "k", 6); !errors.Is(err, ErrInvalidCost) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCost)
+	}
+}
+
+func TestRedisSlidingWindowLimiterLegacyMembers(t *testing.T) {
+	l, clock := newTestSlidingWindow(t, time.Second, 2)
+	ctx := context.Background()
+
+	// 旧版本的成员名就是时间戳，没有权重，也没有单独保存的总数
+	now := clock.Now().UnixMilli()
+	l.cmd.ZAdd(ctx, l.windowKeys("k")[0], redis.Z{Score: float64(now), Member: strconv.FormatInt(now, 10)})
+
+	if limited, _ := l.Limit(ctx, "k"); limited {
+		t.Fatal("a legacy member should count as 1")
+	}
+	if limited, _ := l.Limit(ctx, "k"); !limited {
+		t.Fatal("window should be full")
+	}
+}
+
+func TestRedisSlidingWindowLimiterDecide(t *testing.T) {
+	l, clock := newTestSlidingWindow(t, time.Second, 3)
+	ctx := context.Background()
+	start := clock.Now()
+
+	d, err := l.Decide(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Allowed || d.Limit != 3 || d.Remaining != 2 || d.RetryAfter != 0 || !d.ResetAt.Equal(start.Add(time.Second)) {
+		t.Fatalf("decision = %+v, want allowed with 2 left", d)
+	}
+	clock.Advance(200 * time.Millisecond)
+	if d, _ = l.DecideN(ctx, "k", 2); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("decision = %+v, want allowed with 0 left", d)
+	}
+
+	// 窗口满了：ResetAt 是最后一次请求过期的时间，RetryAfter 是腾出足够配额的时间
+	clock.Advance(100 * time.Millisecond)
+	if d, _ = l.Decide(ctx, "k"); d.Allowed || d.Remaining != 0 ||
+		!d.ResetAt.Equal(start.Add(1200*time.Millisecond)) || d.RetryAfter != 700*time.Millisecond {
+		t.Fatalf("decision = %+v, want limited until the first request expires", d)
+	}
+	if d, _ = l.DecideN(ctx, "k", 2); d.Allowed || d.RetryAfter != 900*time.Millisecond {
+		t.Fatalf("decision = %+v, want limited until both requests expire", d)
+	}
+
+	clock.Advance(700 * time.Millisecond)
+	if d, _ = l.Decide(ctx, "k"); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("decision = %+v, want allowed once the first request expired", d)
+	}
+}
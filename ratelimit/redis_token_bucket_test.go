@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRedisTokenBucketLimiter(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	limiter, err := NewRedisTokenBucketLimiter(rdb, 4, time.Second, 2, WithKeyPrefix("rl:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := limiter.(RedisTokenBucketLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	// 满桶允许突发
+	if limited, err := l.LimitN(ctx, "k", 4); limited || err != nil {
+		t.Fatalf("burst should be allowed: %v, %v", limited, err)
+	}
+	if ttl := mr.TTL("rl:k"); ttl != 2*time.Second {
+		t.Fatalf("ttl = %v, want the time to refill an empty bucket", ttl)
+	}
+	d, err := l.Decide(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Allowed || d.Limit != 4 || d.Remaining != 0 || d.RetryAfter != 500*time.Millisecond ||
+		!d.ResetAt.Equal(clock.Now().Add(2*time.Second)) {
+		t.Fatalf("decision = %+v, want limited with retry after 500ms", d)
+	}
+
+	// 补充了半个令牌，仍然不够，但这半个令牌不能丢
+	clock.Advance(250 * time.Millisecond)
+	if d, _ = l.Decide(ctx, "k"); d.Allowed || d.RetryAfter != 250*time.Millisecond {
+		t.Fatalf("decision = %+v, want limited with retry after 250ms", d)
+	}
+	clock.Advance(250 * time.Millisecond)
+	if d, _ = l.Decide(ctx, "k"); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("decision = %+v, want the refilled token consumed", d)
+	}
+
+	// 按权重扣减，令牌不足时整体拒绝，不会只扣一部分
+	clock.Advance(time.Second)
+	if limited, _ := l.LimitN(ctx, "k", 3); !limited {
+		t.Fatal("3 tokens requested with only 2 left should be limited")
+	}
+	if d, _ = l.DecideN(ctx, "k", 2); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("decision = %+v, want 2 refilled tokens consumed", d)
+	}
+
+	// 桶最多补满，不会超过容量
+	clock.Advance(time.Hour)
+	if d, _ = l.Decide(ctx, "k"); !d.Allowed || d.Remaining != 3 {
+		t.Fatalf("decision = %+v, want a full bucket", d)
+	}
+
+	if _, err := l.LimitN(ctx, "k", 5); !errors.Is(err, ErrInvalidCost) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCost)
+	}
+}
+
+func TestRedisTokenBucketLimiterClockSkew(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	clock := newFakeClock()
+	limiter, err := NewRedisTokenBucketLimiter(rdb, 2, time.Second, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := limiter.(RedisTokenBucketLimiter)
+	l.now = clock.Now
+	ctx := context.Background()
+
+	if limited, _ := l.LimitN(ctx, "k", 2); limited {
+		t.Fatal("burst should be allowed")
+	}
+	// 另一个实例的时钟慢了一秒，时间倒退时不补充令牌
+	clock.Advance(-time.Second)
+	if limited, _ := l.Limit(ctx, "k"); !limited {
+		t.Fatal("tokens must not be refilled when the clock goes backwards")
+	}
+}
+
+func TestRedisTokenBucketLimiterInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		interval time.Duration
+		rate     int
+	}{
+		{name: "zero capacity", capacity: 0, interval: time.Second, rate: 10},
+		{name: "zero rate", capacity: 10, interval: time.Second, rate: 0},
+		{name: "sub-millisecond interval", capacity: 10, interval: time.Microsecond, rate: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRedisTokenBucketLimiter(nil, tt.capacity, tt.interval, tt.rate); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestNewDecisionUnexpectedResult(t *testing.T) {
+	now := time.Now()
+	if _, err := newDecision(now, 10, []int64{1, 9}); err == nil {
+		t.Fatal("expected an error for a short script result")
+	}
+	d, err := newDecision(now, 10, []int64{1, 9, 1000, 0})
+	if err != nil || !d.Allowed || d.Remaining != 9 || !d.ResetAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("decision = %+v, %v", d, err)
+	}
+}
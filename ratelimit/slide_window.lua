@@ -1,26 +1,19 @@
--- 1, 2, 3, 4, 5, 6, 7 这是你的元素
--- ZREMRANGEBYSCORE key1 0 6
--- 7 执行完之后
+-- 滑动窗口，算法在 window_check.lua 中，运行前会拼接到本脚本前面
 
 -- 限流对象
 local key = KEYS[1]
+local total_key = KEYS[2]
 -- 窗口大小
 local window = tonumber(ARGV[1])
 -- 阈值
-local threshold = tonumber( ARGV[2])
+local threshold = tonumber(ARGV[2])
 local now = tonumber(ARGV[3])
--- 窗口的起始时间
-local min = now - window
+-- 本次请求的权重
+local cost = tonumber(ARGV[4])
+local member = ARGV[5]
 
-redis.call('ZREMRANGEBYSCORE', key, '-inf', min)
-local cnt = redis.call('ZCOUNT', key, '-inf', '+inf')
--- local cnt = redis.call('ZCOUNT', key, min, '+inf')
-if cnt >= threshold then
-    -- 执行限流
-    return "true"
-else
-    -- 把 score 和 member 都设置成 now
-    redis.call('ZADD', key, now, now)
-    redis.call('PEXPIRE', key, window)
-    return "false"
-end
\ No newline at end of file
+local decision, commit = check_window(key, total_key, now, window, threshold, cost, member)
+if commit ~= nil then
+    commit()
+end
+return decision
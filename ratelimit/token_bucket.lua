@@ -0,0 +1,18 @@
+-- 令牌桶，算法在 bucket_check.lua 中，运行前会拼接到本脚本前面
+
+-- 限流对象
+local key = KEYS[1]
+-- 桶容量，也就是允许的最大突发
+local capacity = tonumber(ARGV[1])
+-- 每 interval 毫秒补充 rate 个令牌
+local interval = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+-- 本次调用要消耗的令牌数
+local cost = tonumber(ARGV[5])
+
+local decision, commit = check_bucket(key, now, capacity, interval, rate, cost)
+if commit ~= nil then
+    commit()
+end
+return decision
@@ -1,7 +1,82 @@
 package ratelimit
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
+// Limiter 限流器，返回 true 表示触发了限流
 type Limiter interface {
 	Limit(ctx context.Context, key string) (bool, error)
 }
+
+// WeightedLimiter 支持一次调用消耗多个单位配额的限流器
+type WeightedLimiter interface {
+	Limiter
+	LimitN(ctx context.Context, key string, n int) (bool, error)
+}
+
+// Decision 一次限流判断的详细结果，可以直接用来生成 X-RateLimit-* 和 Retry-After 响应头
+//
+// 限流器拿不到详细结果时（例如 FallbackLimiter 降级到 FailOpen、FailClosed，或者底层限流器
+// 只实现了 Limiter），只有 Allowed 有效，其余字段为零值。
+type Decision struct {
+	// Allowed 为 true 表示放行
+	Allowed bool
+	// Limit 窗口内（或桶内）允许的总配额
+	Limit int
+	// Remaining 本次判断之后剩余的配额
+	Remaining int
+	// ResetAt 配额完全恢复的时间
+	ResetAt time.Time
+	// RetryAfter 被限流时，调用方至少需要等待多久再重试；放行时为 0
+	RetryAfter time.Duration
+}
+
+// DecisionLimiter 能返回详细判断结果的限流器
+type DecisionLimiter interface {
+	Limiter
+	Decide(ctx context.Context, key string) (Decision, error)
+}
+
+// decideN 按 l 支持的能力消耗 n 个单位的配额，不支持的信息在 Decision 中保持零值
+func decideN(ctx context.Context, l Limiter, key string, n int) (Decision, error) {
+	if wl, ok := l.(interface {
+		DecideN(ctx context.Context, key string, n int) (Decision, error)
+	}); ok {
+		return wl.DecideN(ctx, key, n)
+	}
+	if dl, ok := l.(DecisionLimiter); ok && n == 1 {
+		return dl.Decide(ctx, key)
+	}
+
+	var (
+		limited bool
+		err     error
+	)
+	switch wl, ok := l.(WeightedLimiter); {
+	case ok:
+		limited, err = wl.LimitN(ctx, key, n)
+	case n == 1:
+		limited, err = l.Limit(ctx, key)
+	default:
+		return Decision{}, fmt.Errorf("%w: %T does not support weighted requests", ErrInvalidCost, l)
+	}
+	return Decision{Allowed: !limited}, err
+}
+
+// newDecision 把 Lua 脚本返回的 {是否放行, 剩余配额, 恢复毫秒数, 重试毫秒数} 转换成 Decision，
+// 结果格式不对时返回错误，不能当成拒绝处理
+func newDecision(now time.Time, limit int, vals []int64) (Decision, error) {
+	if len(vals) != 4 {
+		return Decision{}, fmt.Errorf("ratelimit: unexpected script result length %d", len(vals))
+	}
+	return Decision{
+		Allowed:    vals[0] == 1,
+		Limit:      limit,
+		Remaining:  int(vals[1]),
+		ResetAt:    now.Add(time.Duration(vals[2]) * time.Millisecond),
+		RetryAfter: time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,78 @@
+-- 滑动窗口算法，slide_window.lua 和 composite.lua 共用。
+-- ZSET 里每个成员代表一次请求，score 是请求时间，
+-- 成员名的格式是 "时间:唯一ID:权重"，同一毫秒内的多次请求不会互相覆盖。
+-- 窗口内的权重总和单独保存在 total_key 里，不用每次都遍历整个 ZSET。
+
+-- 旧版本的成员名就是时间戳，没有权重，按 1 计算
+local function cost_of(m)
+    local c = string.match(m, ':(%d+)$')
+    if c == nil then
+        return 1
+    end
+    return tonumber(c)
+end
+
+-- 检查本次请求能否放行，返回判断结果和扣减配额的函数，被限流时扣减函数为 nil。
+-- 判断结果依次是：是否放行, 剩余配额, 多久后窗口内的请求全部过期, 多久后可以重试（单位都是毫秒）
+local function check_window(key, total_key, now, window, threshold, cost, member)
+    -- 窗口的起始时间
+    local min = now - window
+    local total = tonumber(redis.call('GET', total_key))
+    local expired = redis.call('ZRANGEBYSCORE', key, '-inf', min)
+    if #expired > 0 then
+        redis.call('ZREMRANGEBYSCORE', key, '-inf', min)
+    end
+    if total == nil then
+        -- 总数丢失（或者是旧版本留下的数据），重新统计一次
+        total = 0
+        for _, m in ipairs(redis.call('ZRANGE', key, 0, -1)) do
+            total = total + cost_of(m)
+        end
+    elseif #expired > 0 then
+        for _, m in ipairs(expired) do
+            total = total - cost_of(m)
+        end
+        -- 即使本次被限流也要保存新的总数，过期时间和 ZSET 保持一致
+        local ttl = redis.call('PTTL', key)
+        if ttl > 0 then
+            redis.call('SET', total_key, total, 'PX', ttl)
+        else
+            redis.call('DEL', total_key)
+            total = 0
+        end
+    end
+
+    if total + cost > threshold then
+        -- 从最早的请求开始，找到过期后能腾出足够配额的那一个
+        local last = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+        if #last == 0 then
+            return {0, threshold - total, window, window}, nil
+        end
+        local retry = window
+        local freed = 0
+        local start = 0
+        local found = false
+        while not found do
+            local batch = redis.call('ZRANGE', key, start, start + 99, 'WITHSCORES')
+            if #batch == 0 then
+                break
+            end
+            for i = 1, #batch, 2 do
+                freed = freed + cost_of(batch[i])
+                if total - freed + cost <= threshold then
+                    retry = tonumber(batch[i + 1]) + window - now
+                    found = true
+                    break
+                end
+            end
+            start = start + 100
+        end
+        return {0, threshold - total, tonumber(last[2]) + window - now, retry}, nil
+    end
+
+    return {1, threshold - total - cost, window, 0}, function()
+        redis.call('ZADD', key, now, member)
+        redis.call('SET', total_key, total + cost, 'PX', window)
+        redis.call('PEXPIRE', key, window)
+    end
+end